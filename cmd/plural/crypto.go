@@ -27,12 +27,22 @@ context.yaml filter=plural-crypt diff=plural-crypt
 workspace.yaml filter=plural-crypt diff=plural-crypt
 context.yaml* filter=plural-crypt diff=plural-crypt
 workspace.yaml* filter=plural-crypt diff=plural-crypt
+.plural/history.jsonl filter=plural-crypt diff=plural-crypt
+.plural/plans/** filter=plural-crypt diff=plural-crypt
+/environments/*/diffs/**/* filter=plural-crypt diff=plural-crypt
+/environments/*/.plural/history.jsonl filter=plural-crypt diff=plural-crypt
+/environments/*/.plural/plans/** filter=plural-crypt diff=plural-crypt
 .gitattributes !filter !diff
 `
 
 const gitignore = `/**/.terraform
 /**/.terraform*
 /**/terraform.tfstate*
+/**/.plural/logs
+/.plural-lock*
+/.plural/cache
+/environments/*/.plural-lock*
+/environments/*/.plural/cache
 /bin
 *~
 .idea
@@ -174,30 +184,36 @@ func handleDecrypt(c *cli.Context) error {
 	return nil
 }
 
-func cryptoInit(c *cli.Context) error {
-	encryptConfig := [][]string{
-		{"filter.plural-crypt.smudge", "plural crypto decrypt"},
-		{"filter.plural-crypt.clean", "plural crypto encrypt"},
-		{"filter.plural-crypt.required", "true"},
-		{"diff.plural-crypt.textconv", "plural crypto decrypt"},
-	}
+var cryptFilters = [][]string{
+	{"filter.plural-crypt.smudge", "plural crypto decrypt"},
+	{"filter.plural-crypt.clean", "plural crypto encrypt"},
+	{"filter.plural-crypt.required", "true"},
+	{"diff.plural-crypt.textconv", "plural crypto decrypt"},
+}
 
+func cryptoInit(c *cli.Context) error {
 	utils.Highlight("Creating git encryption filters\n\n")
-	for _, conf := range encryptConfig {
+	for _, conf := range cryptFilters {
 		if err := gitConfig(conf[0], conf[1]); err != nil {
 			return err
 		}
 	}
 
-	if err := utils.WriteFile(".gitattributes", []byte(gitattributes)); err != nil {
+	// these are shared by every environment, so always live at the top of the repo
+	root, err := git.TopLevel()
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFile(filepath.Join(root, ".gitattributes"), []byte(gitattributes)); err != nil {
 		return err
 	}
 
-	if err := utils.WriteFile(".gitignore", []byte(gitignore)); err != nil {
+	if err := utils.WriteFile(filepath.Join(root, ".gitignore"), []byte(gitignore)); err != nil {
 		return err
 	}
 
-	_, err := crypto.Build()
+	_, err = crypto.Build()
 	return err
 }
 
@@ -225,7 +241,7 @@ func handleSetupKeys(c *cli.Context) error {
 }
 
 func handleUnlock(c *cli.Context) error {
-	repoRoot, err := git.Root()
+	repoRoot, err := git.TopLevel()
 	if err != nil {
 		return err
 	}
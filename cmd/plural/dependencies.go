@@ -1,12 +1,58 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
 	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/wkspace"
 	"github.com/urfave/cli"
 )
 
+func depsCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "graph",
+			Usage: "exports the dependency graph between the repos in your workspace, highlighting any cycle",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "output format, one of dot, mermaid or json",
+					Value: "dot",
+				},
+				cli.BoolFlag{
+					Name:  "api",
+					Usage: "build the graph from your installations in plural rather than the local manifests",
+				},
+			},
+			Action: handleDepsGraph,
+		},
+		{
+			Name:      "impact",
+			Usage:     "lists every repo that depends on REPO, directly or transitively",
+			ArgsUsage: "REPO",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "output format, either table or json",
+					Value: "table",
+				},
+			},
+			Action: requireArgs(handleDepsImpact, []string{"REPO"}),
+		},
+		{
+			Name:      "why",
+			Usage:     "shows the chain of dependencies through which A depends on B",
+			ArgsUsage: "A B",
+			Action:    requireArgs(handleDepsWhy, []string{"A", "B"}),
+		},
+	}
+}
+
 func topsort(c *cli.Context) error {
 	client := api.NewClient()
 	installations, _ := client.GetInstallations()
@@ -21,3 +67,117 @@ func topsort(c *cli.Context) error {
 	}
 	return nil
 }
+
+func handleDepsGraph(c *cli.Context) error {
+	graph, err := dependencyGraph(c.Bool("api"))
+	if err != nil {
+		return err
+	}
+
+	res, err := graph.Render(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(res)
+	if len(graph.Cycle) > 0 {
+		utils.Warn("cycle detected in dependency graph: %s\n", strings.Join(graph.Cycle, " -> "))
+	}
+	return nil
+}
+
+func dependencyGraph(fromApi bool) (*wkspace.DepGraph, error) {
+	if fromApi {
+		installations, err := api.NewClient().GetInstallations()
+		if err != nil {
+			return nil, err
+		}
+		return wkspace.ApiGraph(installations)
+	}
+
+	repos, err := wkspace.LocalRepos()
+	if err != nil {
+		return nil, err
+	}
+	return wkspace.LocalGraph(repos)
+}
+
+func handleDepsImpact(c *cli.Context) error {
+	repo := c.Args().Get(0)
+	graph, err := dependencyGraph(false)
+	if err != nil {
+		return err
+	}
+
+	if !graph.HasNode(repo) {
+		return fmt.Errorf("%s is not a repo in this workspace", repo)
+	}
+
+	impacts := graph.Dependents(repo)
+	if c.String("format") == "json" {
+		io, err := json.MarshalIndent(impacts, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(io))
+		return nil
+	}
+
+	if len(impacts) == 0 {
+		utils.Success("nothing depends on %s\n", repo)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repo", "Dependency", "Via"})
+	for _, impact := range impacts {
+		kind := "direct"
+		if len(impact.Via) > 0 {
+			kind = "transitive"
+		}
+		table.Append([]string{impact.Repo, kind, strings.Join(impact.Via, " -> ")})
+	}
+	table.Render()
+	return nil
+}
+
+func handleDepsWhy(c *cli.Context) error {
+	a, b := c.Args().Get(0), c.Args().Get(1)
+	graph, err := dependencyGraph(false)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range []string{a, b} {
+		if !graph.HasNode(repo) {
+			return fmt.Errorf("%s is not a repo in this workspace", repo)
+		}
+	}
+
+	path := graph.Path(a, b)
+	if path == nil {
+		return fmt.Errorf("%s does not depend on %s", a, b)
+	}
+
+	utils.Highlight("%s", a)
+	for _, edge := range path {
+		fmt.Printf(" -[%s]-> ", strings.Join(edge.Types, ","))
+		utils.Highlight("%s", edge.To)
+	}
+	fmt.Println()
+	return nil
+}
+
+// dependentsOf lists the repos in the workspace still depending on repo
+func dependentsOf(repo string) ([]string, error) {
+	dependents := []string{}
+	graph, err := dependencyGraph(false)
+	if err != nil || !graph.HasNode(repo) {
+		return dependents, err
+	}
+
+	for _, impact := range graph.Dependents(repo) {
+		dependents = append(dependents, impact.Repo)
+	}
+	return dependents, nil
+}
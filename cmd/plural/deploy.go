@@ -2,15 +2,18 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/diff"
 	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/notify"
 	"github.com/pluralsh/plural/pkg/scaffold"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/errors"
@@ -89,25 +92,37 @@ func diffed(c *cli.Context) error {
 	return nil
 }
 
-func build(c *cli.Context) error {
+func build(c *cli.Context) (err error) {
 	if err := validateOwner(); err != nil {
 		return err
 	}
 
-	changed, err := git.HasUpstreamChanges()
-	if err != nil {
-		return errors.ErrorWrap(noGit, "Failed to get git information")
-	}
-
 	force := c.Bool("force")
-	if !changed && !force {
-		return errors.ErrorWrap(remoteDiff, "Local Changes out of Sync")
+	offline := c.Bool("offline")
+	if !offline {
+		changed, err := git.HasUpstreamChanges()
+		if err != nil {
+			return errors.ErrorWrap(noGit, "Failed to get git information")
+		}
+
+		if !changed && !force {
+			return errors.ErrorWrap(remoteDiff, "Local Changes out of Sync")
+		}
 	}
 
 	if err := repoRoot(); err != nil {
 		return err
 	}
 
+	root, _ := git.Root()
+	api.EnableCache(root, offline)
+	if offline {
+		utils.Warn("Building offline from the cache in %s, nothing will be fetched from plural\n", filepath.Join(root, ".plural", "cache"))
+	}
+
+	report := executor.NewReport("build")
+	defer func() { recordHistory(report, err) }()
+
 	client := api.NewClient()
 	if c.IsSet("only") {
 		installation, err := client.GetInstallation(c.String("only"))
@@ -117,7 +132,7 @@ func build(c *cli.Context) error {
 			return utils.HighlightError(fmt.Errorf("%s is not installed. Please install it with `plural bundle install`", c.String("only")))
 		}
 
-		return doBuild(client, installation, force)
+		return doBuild(client, installation, force, report)
 	}
 
 	installations, err := getSortedInstallations("", client)
@@ -126,15 +141,21 @@ func build(c *cli.Context) error {
 	}
 
 	for _, installation := range installations {
-		if err := doBuild(client, installation, force); err != nil {
+		if err := doBuild(client, installation, force, report); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func doBuild(client *api.Client, installation *api.Installation, force bool) error {
+func doBuild(client *api.Client, installation *api.Installation, force bool, report *executor.Report) (err error) {
 	repoName := installation.Repository.Name
+	repoReport := executor.NewRepoReport(repoName)
+	defer func() {
+		repoReport.Finish(err)
+		report.Add(repoReport)
+	}()
+
 	fmt.Printf("Building workspace for %s\n", repoName)
 	workspace, err := wkspace.New(client, installation)
 	if err != nil {
@@ -195,7 +216,7 @@ func doValidate(client *api.Client, installation *api.Installation) error {
 	return workspace.Validate()
 }
 
-func deploy(c *cli.Context) error {
+func deploy(c *cli.Context) (err error) {
 	if err := validateOwner(); err != nil {
 		return err
 	}
@@ -222,38 +243,93 @@ func deploy(c *cli.Context) error {
 		}
 	}
 
-	fmt.Printf("Deploying applications [%s] in topological order\n\n", strings.Join(sorted, ", "))
-
 	ignoreConsole := c.Bool("ignore-console")
+	repos := make([]string, 0)
 	for _, repo := range sorted {
 		if ignoreConsole && (repo == "console" || repo == "bootstrap") {
 			continue
 		}
+		repos = append(repos, repo)
+	}
 
-		execution, err := executor.GetExecution(filepath.Join(repoRoot, repo), "deploy")
-		if err != nil {
-			return err
+	repos, err = resumeFrom(repos, c.String("from"))
+	if err != nil {
+		return err
+	}
+
+	if c.IsSet("step") && !c.IsSet("from") {
+		return fmt.Errorf("--step can only be used alongside --from")
+	}
+
+	d := &deployer{
+		repoRoot:    repoRoot,
+		pipeline:    executor.DeployPipeline,
+		parallelism: c.Int("parallelism"),
+		from:        c.String("from"),
+		step:        c.String("step"),
+		onlyStep:    c.String("only-step"),
+		plans:       c.Bool("plans"),
+		report:      executor.NewReport("deploy"),
+	}
+
+	if c.Bool("dry-run") {
+		return d.dryRun(repos)
+	}
+
+	l, err := acquireLock("deploy")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	defer flushReport(d.report, c.String("report"))
+	defer func() { recordHistory(d.report, err) }()
+	notified := false
+	notifyOnce := func(err error) {
+		if !notified {
+			notified = true
+			notifyWebhooks(d.report, err)
 		}
+	}
+	defer func() { notifyOnce(err) }()
 
-		if err := execution.Execute(); err != nil {
+	waves, err := d.waves(repos)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deploying applications [%s] in topological order\n\n", strings.Join(repos, ", "))
+
+	for _, wave := range waves {
+		if err := d.deployWave(wave); err != nil {
 			utils.Note("It looks like your deployment failed, feel free to reach out to us on discord or intercom and we should be able to help you out\n")
 			return err
 		}
-		fmt.Printf("\n")
 
-		installation, err := client.GetInstallation(repo)
-		if err != nil {
-			return err
-		}
-		if c.Bool("silence") {
-			continue
-		}
+		for _, repo := range wave {
+			installation, err := client.GetInstallation(repo)
+			if err != nil {
+				return err
+			}
+			if c.Bool("silence") {
+				continue
+			}
 
-		if err := scaffold.Notes(installation); err != nil {
-			return err
+			if err := scaffold.Notes(installation); err != nil {
+				return err
+			}
 		}
 	}
 
+	// release before committing so a stale lock file can never be pushed, and record
+	// the deploy so it's committed alongside it
+	if err := l.Release(); err != nil {
+		utils.Warn("failed to release the deploy lock: %s\n", err)
+	}
+	recordHistory(d.report, nil)
+	// don't hold the notification up behind the commit prompt
+	notifyOnce(nil)
+
 	utils.Highlight("\n==> Commit and push your changes to record your deployment\n\n")
 
 	if commit := commitMsg(c); commit != "" {
@@ -264,6 +340,45 @@ func deploy(c *cli.Context) error {
 	return nil
 }
 
+func flushReport(report *executor.Report, path string) {
+	if path == "" {
+		return
+	}
+
+	if err := report.Flush(path); err != nil {
+		utils.Warn("failed to write report to %s: %s\n", path, err)
+	}
+}
+
+func notifyWebhooks(report *executor.Report, err error) {
+	man, manErr := manifest.FetchProject()
+	if manErr != nil || len(man.Webhooks) == 0 {
+		return
+	}
+
+	conf := config.Read()
+	root, _ := git.Root()
+	commit, _ := git.Head(root)
+	payload := notify.FromReport(report, man.Cluster, conf.Email, commit, err)
+	if err := notify.Send(man.Webhooks, payload); err != nil {
+		utils.Warn("failed to send deploy notification: %s\n", err)
+	}
+}
+
+func resumeFrom(repos []string, from string) ([]string, error) {
+	if from == "" {
+		return repos, nil
+	}
+
+	for i, repo := range repos {
+		if repo == from {
+			return repos[i:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not one of the repos being deployed: [%s]", from, strings.Join(repos, ", "))
+}
+
 func commitMsg(c *cli.Context) string {
 	if commit := c.String("commit"); commit != "" {
 		return commit
@@ -279,6 +394,10 @@ func commitMsg(c *cli.Context) string {
 }
 
 func handleDiff(c *cli.Context) error {
+	if format := c.String("format"); format != diff.FormatMarkdown && format != diff.FormatJson {
+		return fmt.Errorf("unknown summary format %s, must be one of %s or %s", format, diff.FormatMarkdown, diff.FormatJson)
+	}
+
 	repoRoot, err := git.Root()
 	if err != nil {
 		return err
@@ -291,22 +410,42 @@ func handleDiff(c *cli.Context) error {
 
 	fmt.Printf("Diffing applications [%s] in topological order\n\n", strings.Join(sorted, ", "))
 
+	d := &deployer{repoRoot: repoRoot, pipeline: executor.DiffPipeline, report: executor.NewReport("diff")}
+	defer flushReport(d.report, c.String("report"))
 	for _, repo := range sorted {
-		d, err := diff.GetDiff(filepath.Join(repoRoot, repo), "diff")
-		if err != nil {
-			return err
+		// repos built before diff.hcl existed have nothing to diff
+		if !executor.HasPipeline(filepath.Join(repoRoot, repo), executor.DiffPipeline) {
+			continue
 		}
 
-		if err := d.Execute(); err != nil {
+		if err := d.deployRepo(repo, os.Stdout); err != nil {
 			return err
 		}
 
 		fmt.Printf("\n")
 	}
+
+	if path := c.String("summary"); path != "" {
+		return writeDiffSummary(repoRoot, sorted, path, c.String("format"))
+	}
 	return nil
 }
 
-func bounce(c *cli.Context) error {
+func writeDiffSummary(root string, repos []string, path, format string) error {
+	summary, err := diff.Summarize(root, repos)
+	if err != nil {
+		return err
+	}
+
+	io, err := summary.Render(format)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, io, 0644)
+}
+
+func bounce(c *cli.Context) (err error) {
 	if err := validateOwner(); err != nil {
 		return err
 	}
@@ -318,12 +457,21 @@ func bounce(c *cli.Context) error {
 	}
 	repoName := c.Args().Get(0)
 
+	l, err := acquireLock("bounce")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	report := executor.NewReport("bounce")
+	defer func() { recordHistory(report, err) }()
+
 	if repoName != "" {
 		installation, err := client.GetInstallation(repoName)
 		if err != nil {
 			return err
 		}
-		return doBounce(repoRoot, client, installation)
+		return doBounce(repoRoot, client, installation, report)
 	}
 
 	installations, err := getSortedInstallations(repoName, client)
@@ -332,15 +480,21 @@ func bounce(c *cli.Context) error {
 	}
 
 	for _, installation := range installations {
-		if err := doBounce(repoRoot, client, installation); err != nil {
+		if err := doBounce(repoRoot, client, installation, report); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func doBounce(repoRoot string, client *api.Client, installation *api.Installation) error {
+func doBounce(repoRoot string, client *api.Client, installation *api.Installation, report *executor.Report) (err error) {
 	repoName := installation.Repository.Name
+	repoReport := executor.NewRepoReport(repoName)
+	defer func() {
+		repoReport.Finish(err)
+		report.Add(repoReport)
+	}()
+
 	utils.Warn("bouncing deployments in %s\n", repoName)
 	workspace, err := wkspace.New(client, installation)
 	if err != nil {
@@ -352,29 +506,48 @@ func doBounce(repoRoot string, client *api.Client, installation *api.Installatio
 	return workspace.Bounce()
 }
 
-func destroy(c *cli.Context) error {
+func destroy(c *cli.Context) (err error) {
 	if err := validateOwner(); err != nil {
 		return err
 	}
 
+	repoName := c.Args().Get(0)
+	if repoName != "" {
+		dependents, err := dependentsOf(repoName)
+		if err != nil {
+			utils.Warn("could not check whether anything depends on %s: %s\n", repoName, err)
+		}
+		if len(dependents) > 0 {
+			utils.Warn("%s is still depended on by %s, which will likely break without it (see `plural deps impact %s`)\n", repoName, strings.Join(dependents, ", "), repoName)
+		}
+	}
+
 	if ok := confirm("Are you sure you want to destroy this workspace?"); !ok {
 		return nil
 	}
 
 	client := api.NewClient()
-	repoName := c.Args().Get(0)
 	repoRoot, err := git.Root()
 	if err != nil {
 		return err
 	}
 
+	l, err := acquireLock("destroy")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	report := executor.NewReport("destroy")
+	defer flushReport(report, c.String("report"))
+	defer func() { recordHistory(report, err) }()
 	if repoName != "" {
 		installation, err := client.GetInstallation(repoName)
 		if err != nil {
 			return err
 		}
 
-		return doDestroy(repoRoot, client, installation)
+		return doDestroy(repoRoot, client, installation, report)
 	}
 
 	installations, err := getSortedInstallations(repoName, client)
@@ -394,7 +567,7 @@ func destroy(c *cli.Context) error {
 			continue
 		}
 
-		if err := doDestroy(repoRoot, client, installation); err != nil {
+		if err := doDestroy(repoRoot, client, installation, report); err != nil {
 			return err
 		}
 	}
@@ -409,15 +582,21 @@ func destroy(c *cli.Context) error {
 	return nil
 }
 
-func doDestroy(repoRoot string, client *api.Client, installation *api.Installation) error {
+func doDestroy(repoRoot string, client *api.Client, installation *api.Installation, report *executor.Report) error {
 	os.Chdir(repoRoot)
 	utils.Error("\nDestroying application %s\n", installation.Repository.Name)
+	repoReport := executor.NewRepoReport(installation.Repository.Name)
+	defer report.Add(repoReport)
 	workspace, err := wkspace.New(client, installation)
 	if err != nil {
+		repoReport.Finish(err)
 		return err
 	}
 
-	return workspace.Destroy()
+	workspace.Report = repoReport
+	err = workspace.Destroy()
+	repoReport.Finish(err)
+	return err
 }
 
 func buildContext(c *cli.Context) error {
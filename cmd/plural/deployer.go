@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/wkspace"
+)
+
+// deployer runs a pipeline (deploy, diff or any user defined one) across repos
+type deployer struct {
+	repoRoot    string
+	pipeline    string
+	parallelism int
+	from        string
+	step        string
+	onlyStep    string
+	plans       bool
+	report      *executor.Report
+}
+
+func (d *deployer) execution(repo string) (*executor.Execution, error) {
+	execution, err := executor.GetExecution(filepath.Join(d.repoRoot, repo), d.pipeline)
+	if err != nil {
+		return execution, err
+	}
+
+	if d.onlyStep != "" {
+		if err := execution.Only(d.onlyStep); err != nil {
+			return execution, err
+		}
+	}
+
+	if d.plans {
+		execution.UsePlans()
+	}
+
+	if repo == d.from && d.step != "" {
+		if err := execution.StartAt(d.step); err != nil {
+			return execution, err
+		}
+	}
+
+	return execution, nil
+}
+
+func (d *deployer) dryRun(repos []string) error {
+	fmt.Printf("Planning %s of applications [%s] in topological order\n\n", d.pipeline, strings.Join(repos, ", "))
+	for _, repo := range repos {
+		execution, err := d.execution(repo)
+		if err != nil {
+			return err
+		}
+
+		if err := execution.DryRun(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Printf("\n")
+	}
+
+	return nil
+}
+
+func (d *deployer) deployWave(wave []string) error {
+	if len(wave) == 1 || d.parallelism <= 1 {
+		for _, repo := range wave {
+			if err := d.deployRepo(repo, os.Stdout); err != nil {
+				return err
+			}
+			fmt.Printf("\n")
+		}
+		return nil
+	}
+
+	utils.Highlight("==> running %s for [%s] in parallel\n\n", d.pipeline, strings.Join(wave, ", "))
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, d.parallelism)
+	errs := make([]error, len(wave))
+	for i, repo := range wave {
+		wg.Add(1)
+		go func(i int, repo string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var buf bytes.Buffer
+			errs[i] = d.deployRepo(repo, &buf)
+
+			// flush each repo's output in one piece so concurrent deploys stay readable
+			mut.Lock()
+			defer mut.Unlock()
+			os.Stdout.Write(buf.Bytes())
+			fmt.Printf("\n")
+		}(i, repo)
+	}
+	wg.Wait()
+
+	var failed error
+	for i, err := range errs {
+		if err != nil {
+			utils.Error("%s of %s failed: %s\n", d.pipeline, wave[i], err)
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+
+	return failed
+}
+
+// waves groups repos into batches that can run concurrently, which is one repo at a time
+// unless parallelism is enabled
+func (d *deployer) waves(repos []string) ([][]string, error) {
+	if d.parallelism > 1 {
+		return wkspace.TopSortWaves(repos)
+	}
+
+	waves := make([][]string, len(repos))
+	for i, repo := range repos {
+		waves[i] = []string{repo}
+	}
+	return waves, nil
+}
+
+func (d *deployer) deployRepo(repo string, out io.Writer) error {
+	if d.pipeline == executor.DiffPipeline {
+		if err := diff.Reset(d.repoRoot, repo); err != nil {
+			return err
+		}
+	}
+
+	execution, err := d.execution(repo)
+	if err != nil {
+		repoReport := executor.NewRepoReport(repo)
+		repoReport.Finish(err)
+		d.report.Add(repoReport)
+		return err
+	}
+
+	repoReport, err := execution.Run(out)
+	d.report.Add(repoReport)
+	return err
+}
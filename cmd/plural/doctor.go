@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+type check struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+func passed(name, msg string, args ...interface{}) *check {
+	return &check{Name: name, Status: checkPass, Message: fmt.Sprintf(msg, args...)}
+}
+
+func warned(name, msg, remediation string) *check {
+	return &check{Name: name, Status: checkWarn, Message: msg, Remediation: remediation}
+}
+
+func failed(name, msg, remediation string) *check {
+	return &check{Name: name, Status: checkFail, Message: msg, Remediation: remediation}
+}
+
+func handleDoctor(c *cli.Context) error {
+	asJson := c.String("format") == "json"
+	checks := doctorChecks()
+
+	if asJson {
+		io, err := json.MarshalIndent(map[string]interface{}{
+			"version": Version,
+			"commit":  GitCommit,
+			"checks":  checks,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(io))
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Check", "Status", "Message"})
+		for _, ch := range checks {
+			table.Append([]string{ch.Name, ch.Status, ch.Message})
+		}
+		table.Render()
+
+		for _, ch := range checks {
+			if ch.Remediation != "" {
+				fmt.Printf("\n")
+				if ch.Status == checkFail {
+					utils.Error("%s: ", ch.Name)
+				} else {
+					utils.Highlight("%s: ", ch.Name)
+				}
+				fmt.Println(ch.Remediation)
+			}
+		}
+	}
+
+	count := 0
+	for _, ch := range checks {
+		if ch.Status == checkFail {
+			count++
+		}
+	}
+
+	if count > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d checks failed", count, len(checks)), 1)
+	}
+	return nil
+}
+
+func doctorChecks() []*check {
+	checks := []*check{}
+	for _, tool := range []string{"helm", "kubectl", "terraform", "git"} {
+		checks = append(checks, checkTool(tool))
+	}
+
+	checks = append(checks, checkHelmPush())
+	checks = append(checks, checkGitRepo())
+	checks = append(checks, checkCryptFilters())
+	checks = append(checks, checkGitattributes())
+	checks = append(checks, checkKeyFingerprint())
+	checks = append(checks, checkOwner())
+	checks = append(checks, checkKubeContext())
+	checks = append(checks, checkApi())
+	return checks
+}
+
+func checkTool(tool string) *check {
+	name := tool + " version"
+	vsn, err := utils.ToolVersion(tool)
+	if err != nil {
+		return failed(name, err.Error(), fmt.Sprintf("install %s and make sure it is on your PATH (binaries in the bin directory of your repo take precedence)", tool))
+	}
+
+	if err := utils.CheckTool(tool); err != nil {
+		return failed(name, err.Error(), fmt.Sprintf("install a version of %s matching the tools section of workspace.yaml", tool))
+	}
+
+	_, path := utils.Which(tool)
+	return passed(name, "%s (%s)", vsn, path)
+}
+
+func checkHelmPush() *check {
+	name := "helm push plugin"
+	res, _ := exec.Command("helm", "plugin", "list").Output()
+	if !strings.Contains(string(res), "cm-push") {
+		return failed(name, "the cm-push plugin is not installed", "run `helm plugin install https://github.com/pluralsh/helm-push`")
+	}
+
+	return passed(name, "cm-push is installed")
+}
+
+func checkGitRepo() *check {
+	name := "git repository"
+	root, err := git.Root()
+	if err != nil {
+		return failed(name, "not in a git repository", "run plural from within your workspace repository")
+	}
+
+	if _, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output(); err != nil {
+		return failed(name, "the repository has no initial commit", "commit something, eg `git commit --allow-empty -m init`")
+	}
+
+	return passed(name, "%s", root)
+}
+
+func checkCryptFilters() *check {
+	name := "git crypt filters"
+	missing := []string{}
+	for _, conf := range cryptFilters {
+		val, err := exec.Command("git", "config", "--get", conf[0]).Output()
+		if err != nil || strings.TrimSpace(string(val)) != conf[1] {
+			missing = append(missing, conf[0])
+		}
+	}
+
+	if len(missing) > 0 {
+		return failed(name, fmt.Sprintf("not configured: %s", strings.Join(missing, ", ")), "run `plural crypto init`")
+	}
+
+	return passed(name, "all %d filters configured", len(cryptFilters))
+}
+
+func checkGitattributes() *check {
+	name := ".gitattributes"
+	root, err := git.TopLevel()
+	if err != nil {
+		return failed(name, "not in a git repository", "run plural from within your workspace repository")
+	}
+
+	if !utils.Exists(filepath.Join(root, ".gitattributes")) {
+		return failed(name, ".gitattributes is missing, secrets will be committed in plaintext", "run `plural crypto init`")
+	}
+
+	missing, err := git.MissingAttributes(root, strings.Split(gitattributes, "\n"))
+	if err != nil {
+		return failed(name, fmt.Sprintf("could not read .gitattributes: %s", err), "run `plural crypto init`")
+	}
+
+	if len(missing) > 0 {
+		patterns := make([]string, len(missing))
+		for i, line := range missing {
+			patterns[i] = strings.Fields(line)[0]
+		}
+		return failed(name, fmt.Sprintf("missing entries, so these are committed as is: %s", strings.Join(patterns, ", ")), "run `plural crypto init`, or add the missing entries to .gitattributes")
+	}
+
+	return passed(name, "every entry present")
+}
+
+func checkKeyFingerprint() *check {
+	name := "encryption key"
+	root, _ := utils.ProjectRoot()
+	if !utils.Exists(filepath.Join(root, "crypto.yml")) {
+		return warned(name, "crypto.yml is missing, so the key can't be verified", "run `plural crypto init` and commit crypto.yml")
+	}
+
+	conf, err := crypto.ReadConfig()
+	if err != nil {
+		return failed(name, fmt.Sprintf("could not read crypto.yml: %s", err), "restore crypto.yml from git history")
+	}
+
+	prov, err := crypto.Build()
+	if err != nil {
+		return failed(name, err.Error(), "import the key this repo was encrypted with using `plural crypto import`")
+	}
+
+	if prov.ID() != conf.Id {
+		return failed(name, fmt.Sprintf("local key %s doesn't match %s in crypto.yml", prov.ID(), conf.Id), "import the key this repo was encrypted with using `plural crypto import`")
+	}
+
+	return passed(name, "%s", prov.ID())
+}
+
+func checkOwner() *check {
+	name := "workspace owner"
+	if err := validateOwner(); err != nil {
+		return failed(name, err.Error(), "switch to the owning profile with `plural profile use`, or log in as the owner")
+	}
+
+	return passed(name, "%s", config.Read().Email)
+}
+
+func checkKubeContext() *check {
+	name := "kube context"
+	project, err := manifest.ReadProject(manifest.ProjectManifestPath())
+	if err != nil {
+		return warned(name, "no workspace.yaml, so there is no cluster to compare against", "run `plural init`")
+	}
+	if project.Cluster == "" {
+		return warned(name, "workspace.yaml has no cluster, so there is nothing to compare against", "set cluster in workspace.yaml")
+	}
+
+	kubeconf, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return failed(name, fmt.Sprintf("could not load your kubeconfig: %s", err), "run `plural wkspace kube-init REPO`")
+	}
+
+	current := kubeconf.CurrentContext
+	ctx, ok := kubeconf.Contexts[current]
+	if current == "" || !ok {
+		return failed(name, "no current kube context", "run `plural wkspace kube-init REPO`")
+	}
+
+	if kubeClusterName(project.Provider, ctx.Cluster) != project.Cluster {
+		return failed(name, fmt.Sprintf("current context %s points at cluster %s, not %s", current, ctx.Cluster, project.Cluster), "run `plural wkspace kube-init REPO`")
+	}
+
+	return passed(name, "%s", current)
+}
+
+// kubeClusterName pulls the cluster's own name out of the name each provider's cli gives
+// its kubeconfig entry, eg arn:aws:eks:us-east-2:123456789012:cluster/NAME for eks
+func kubeClusterName(prov, cluster string) string {
+	switch prov {
+	case provider.AWS:
+		if i := strings.LastIndex(cluster, ":cluster/"); i >= 0 {
+			return cluster[i+len(":cluster/"):]
+		}
+	case provider.GCP:
+		if parts := strings.SplitN(cluster, "_", 4); len(parts) == 4 && parts[0] == "gke" {
+			return parts[3]
+		}
+	case provider.KIND:
+		return strings.TrimPrefix(cluster, "kind-")
+	}
+	return cluster
+}
+
+func checkApi() *check {
+	name := "plural api"
+	conf := config.Read()
+	me, err := api.NewClient().Me()
+	if err != nil {
+		return failed(name, fmt.Sprintf("could not reach %s: %s", conf.BaseUrl(), err), "check your network connection, then run `plural login`")
+	}
+
+	return passed(name, "%s as %s", conf.BaseUrl(), me.Email)
+}
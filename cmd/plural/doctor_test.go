@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/provider"
+)
+
+func TestCheckTool(t *testing.T) {
+	bin, err := ioutil.TempDir("", "doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+
+	// what each tool prints for the version args doctor passes it
+	outputs := map[string]string{
+		"helm":      "v3.5.2+g167aac7",
+		"kubectl":   `{"clientVersion": {"major": "1", "minor": "20", "gitVersion": "v1.20.4"}}`,
+		"terraform": "Terraform v1.0.11\non linux_amd64",
+		"git":       "not a version",
+	}
+	for tool, out := range outputs {
+		script := "#!/bin/sh\ncat <<'EOF'\n" + out + "\nEOF\n"
+		if err := ioutil.WriteFile(filepath.Join(bin, tool), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+
+	tests := []struct {
+		tool    string
+		status  string
+		message string
+	}{
+		{tool: "helm", status: checkPass, message: "3.5.2"},
+		{tool: "kubectl", status: checkPass, message: "1.20.4"},
+		{tool: "terraform", status: checkPass, message: "1.0.11"},
+		{tool: "git", status: checkFail, message: "could not parse the version of git"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tool, func(t *testing.T) {
+			ch := checkTool(test.tool)
+			if ch.Status != test.status || !strings.HasPrefix(ch.Message, test.message) {
+				t.Errorf("checkTool(%s) = %s %q, expected %s %q", test.tool, ch.Status, ch.Message, test.status, test.message)
+			}
+			if test.status == checkFail && ch.Remediation == "" {
+				t.Errorf("expected a failed check to say how to fix it")
+			}
+		})
+	}
+}
+
+func TestKubeClusterName(t *testing.T) {
+	tests := []struct {
+		prov     string
+		cluster  string
+		expected string
+	}{
+		{prov: provider.AWS, cluster: "arn:aws:eks:us-east-2:123456789012:cluster/plural", expected: "plural"},
+		{prov: provider.AWS, cluster: "plural-dev", expected: "plural-dev"},
+		{prov: provider.GCP, cluster: "gke_my-project_us-east1-b_plural", expected: "plural"},
+		{prov: provider.GCP, cluster: "gke_my-project_us-east1-b", expected: "gke_my-project_us-east1-b"},
+		{prov: provider.KIND, cluster: "kind-plural", expected: "plural"},
+		{prov: provider.AZURE, cluster: "plural", expected: "plural"},
+	}
+
+	for _, test := range tests {
+		if name := kubeClusterName(test.prov, test.cluster); name != test.expected {
+			t.Errorf("kubeClusterName(%s, %s) = %s, expected %s", test.prov, test.cluster, name, test.expected)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+func handleDrift(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos := []string(c.Args())
+	if len(repos) == 0 {
+		installations, err := getSortedInstallations("", api.NewClient())
+		if err != nil {
+			return err
+		}
+
+		for _, inst := range installations {
+			repos = append(repos, inst.Repository.Name)
+		}
+	}
+
+	asJson := c.String("format") == "json"
+	drifts := make([]*wkspace.Drift, 0, len(repos))
+	for i, repo := range repos {
+		minimal, err := wkspace.Minimal(repo)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			minimal.Provider.KubeConfig()
+		}
+
+		if !asJson {
+			utils.Highlight("checking %s for drift...\n", repo)
+		}
+		drifts = append(drifts, minimal.Drift(root))
+	}
+
+	if asJson {
+		io, err := json.MarshalIndent(drifts, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(io))
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Repo", "Terraform", "Helm", "Summary", "Error"})
+		for _, d := range drifts {
+			table.Append([]string{d.Repo, d.Terraform, d.Helm, d.Summary, d.Error})
+		}
+		table.Render()
+	}
+
+	drifted, failed := []string{}, []string{}
+	for _, d := range drifts {
+		if d.Drifted() {
+			drifted = append(drifted, d.Repo)
+		}
+		if d.Error != "" {
+			failed = append(failed, d.Repo)
+		}
+	}
+
+	if len(drifted) > 0 {
+		return cli.NewExitError(fmt.Sprintf("drift detected in [%s]", strings.Join(drifted, ", ")), 2)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not check [%s] for drift", strings.Join(failed, ", "))
+	}
+	return nil
+}
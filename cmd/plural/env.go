@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/environment"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func envCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "lists the environments in this workspace",
+			Action: handleEnvList,
+		},
+		{
+			Name:      "create",
+			Usage:     "creates a new environment, with its own workspace.yaml and context.yaml overlays",
+			ArgsUsage: "NAME",
+			Action:    requireArgs(handleEnvCreate, []string{"NAME"}),
+		},
+	}
+}
+
+// selectEnv activates the environment named by --env or PLURAL_ENV, or the one
+// containing the working directory, and moves into it so relative paths resolve there
+func selectEnv(c *cli.Context) error {
+	top, err := git.TopLevel()
+	if err != nil {
+		return nil
+	}
+
+	wd, _ := os.Getwd()
+	env := c.GlobalString("env")
+	if env == "" {
+		env = environment.Detect(top, wd)
+	}
+
+	if env == "" {
+		return nil
+	}
+
+	if err := environment.Validate(env); err != nil {
+		return err
+	}
+
+	environment.Select(env)
+	dir := environment.Path(top)
+	if !utils.Exists(dir) {
+		return fmt.Errorf("there is no %s environment, create it with `plural env create %s`", env, env)
+	}
+
+	if environment.Detect(top, wd) != env {
+		return os.Chdir(dir)
+	}
+	return nil
+}
+
+func handleEnvList(c *cli.Context) error {
+	top, err := git.TopLevel()
+	if err != nil {
+		return err
+	}
+
+	envs, err := environment.List(top)
+	if err != nil {
+		return err
+	}
+
+	for _, env := range envs {
+		if env == environment.Current() {
+			utils.Highlight("* %s\n", env)
+			continue
+		}
+		fmt.Printf("  %s\n", env)
+	}
+	return nil
+}
+
+func handleEnvCreate(c *cli.Context) error {
+	name := c.Args().First()
+	if err := environment.Validate(name); err != nil {
+		return err
+	}
+
+	top, err := git.TopLevel()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(top, environment.Dir, name)
+	if utils.Exists(dir) {
+		return fmt.Errorf("the %s environment already exists", name)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	utils.Success("Created %s\n", dir)
+	fmt.Printf("Add a workspace.yaml and context.yaml there with whatever differs from the shared ones, eg cluster, bucket and domains, then run `plural --env %s build`\n", name)
+	return nil
+}
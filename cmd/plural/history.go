@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func recordHistory(report *executor.Report, err error) {
+	root, rootErr := git.Root()
+	if rootErr != nil {
+		return
+	}
+
+	if err := report.Record(root, err); err != nil {
+		utils.Warn("failed to record %s in the workspace history: %s\n", report.Command, err)
+	}
+}
+
+func handleHistory(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	since, err := parseSince(c.String("since"))
+	if err != nil {
+		return err
+	}
+
+	entries, err := executor.ReadHistory(root)
+	if err != nil {
+		return err
+	}
+
+	entries = filterHistory(entries, c.Args().Get(0), since)
+	if c.String("format") == "json" {
+		io, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(io))
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Started", "User", "Command", "Commit", "Result", "Repos"})
+	for _, entry := range entries {
+		repos := make([]string, 0, len(entry.Repos))
+		for _, repo := range entry.Repos {
+			if len(repo.Steps) == 0 {
+				repos = append(repos, repo.Repo)
+				continue
+			}
+			repos = append(repos, fmt.Sprintf("%s (%s)", repo.Repo, strings.Join(repo.Steps, ", ")))
+		}
+
+		commit := entry.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		table.Append([]string{
+			entry.Started.Local().Format("2006-01-02 15:04:05"),
+			entry.User,
+			entry.Command,
+			commit,
+			entry.Result,
+			strings.Join(repos, "\n"),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+// parseSince accepts either a duration like 72h or a date like 2021-06-01
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not parse --since %s, use a duration like 72h or a date like 2006-01-02", since)
+}
+
+func filterHistory(entries []*executor.HistoryEntry, repo string, since time.Time) []*executor.HistoryEntry {
+	result := make([]*executor.HistoryEntry, 0)
+	for _, entry := range entries {
+		if entry.Started.Before(since) {
+			continue
+		}
+
+		if repo == "" {
+			result = append(result, entry)
+			continue
+		}
+
+		for _, r := range entry.Repos {
+			if r.Repo == repo {
+				filtered := *entry
+				filtered.Repos = []*executor.HistoryRepo{r}
+				result = append(result, &filtered)
+				break
+			}
+		}
+	}
+	return result
+}
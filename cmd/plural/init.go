@@ -60,7 +60,7 @@ func handleLogin(c *cli.Context) error {
 
 	fmt.Printf("logging in at %s\n", device.LoginUrl)
 	if err := browser.OpenURL(device.LoginUrl); err != nil {
-		fmt.Println("Open %s in your browser to proceed")
+		fmt.Printf("Open %s in your browser to proceed\n", device.LoginUrl)
 	}
 
 	var jwt string
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/lock"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func lockCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "status",
+			Usage:  "shows who currently holds the deploy lock for this workspace",
+			Action: handleLockStatus,
+		},
+		{
+			Name:   "break",
+			Usage:  "forcibly releases the deploy lock, only use this if the holder is gone",
+			Action: handleLockBreak,
+		},
+	}
+}
+
+// localLock is set by the global --local-lock flag
+var localLock bool
+
+// acquireLock takes the workspace deploy lock.  If the configured backend can't be
+// reached this fails rather than quietly locking locally, unless --local-lock was given
+// (eg for the very first deploy, before the cluster holding the lock exists)
+func acquireLock(command string) (*lock.Lock, error) {
+	root, err := git.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	// workspaces set up before the lock existed don't ignore its file
+	if err := git.EnsureIgnored(lock.Ignored...); err != nil {
+		utils.Warn("could not add the deploy lock to .gitignore: %s\n", err)
+	}
+
+	if localLock {
+		utils.Warn("taking a local deploy lock, this won't stop deploys from other machines\n")
+		return lock.Acquire(lock.NewLocal(root), command)
+	}
+
+	backend, err := lock.ForWorkspace(root)
+	if err != nil {
+		return nil, lockError(err)
+	}
+
+	l, err := lock.Acquire(backend, command)
+	if _, held := err.(*lock.HeldError); err == nil || held || backend.Name() == lock.BackendLocal {
+		return l, err
+	}
+	return nil, lockError(fmt.Errorf("could not acquire the %s deploy lock: %s", backend.Name(), err))
+}
+
+func lockError(err error) error {
+	return fmt.Errorf("%s\nif the cluster holding the lock doesn't exist yet, rerun with `plural --local-lock`", err)
+}
+
+func handleLockStatus(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	backend, err := lock.ForWorkspace(root)
+	if err != nil {
+		return err
+	}
+
+	lease, err := backend.Get()
+	if err != nil {
+		return err
+	}
+
+	if lease == nil {
+		fmt.Printf("the %s deploy lock is free\n", backend.Name())
+		return nil
+	}
+
+	status := "held"
+	if lease.Expired() {
+		status = "expired"
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Backend", "Holder", "Host", "Command", "Started", "Status"})
+	table.Append([]string{backend.Name(), lease.Holder, lease.Host, lease.Command, lease.Started.Local().Format(time.RFC1123), status})
+	table.Render()
+	return nil
+}
+
+func handleLockBreak(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	backend, err := lock.ForWorkspace(root)
+	if err != nil {
+		return err
+	}
+
+	lease, err := backend.Get()
+	if err != nil {
+		return err
+	}
+
+	if lease == nil {
+		fmt.Printf("the %s deploy lock is already free\n", backend.Name())
+		return nil
+	}
+
+	msg := fmt.Sprintf("This lock is held by %s on %s running `plural %s`, are you sure you want to break it?", lease.Holder, lease.Host, lease.Command)
+	if ok := confirm(msg); !ok {
+		return nil
+	}
+
+	if err := backend.Break(); err != nil {
+		return err
+	}
+
+	utils.Success("broke the %s deploy lock\n", backend.Name())
+	return nil
+}
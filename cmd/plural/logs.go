@@ -1,11 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/logs"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/urfave/cli"
 )
 
@@ -23,6 +29,23 @@ func logsCommands() []cli.Command {
 			ArgsUsage: "REPO NAME",
 			Action:    requireArgs(handleLogTail, []string{"REPO", "NAME"}),
 		},
+		{
+			Name:      "deploy",
+			Usage:     "prints the step logs from the most recent deploy of a repo",
+			ArgsUsage: "REPO",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "step",
+					Usage: "only print the most recent log for this step",
+				},
+				cli.StringFlag{
+					Name:  "pipeline",
+					Usage: "print the logs of this pipeline instead, eg diff",
+					Value: "deploy",
+				},
+			},
+			Action: requireArgs(handleDeployLogs, []string{"REPO"}),
+		},
 	}
 }
 
@@ -55,3 +78,33 @@ func handleLogTail(c *cli.Context) error {
 
 	return logs.Tail(conf.Namespace(repo), name)
 }
+
+func handleDeployLogs(c *cli.Context) error {
+	repo := c.Args().Get(0)
+	repoRoot, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	files, err := executor.LatestPipelineLogs(filepath.Join(repoRoot, repo), c.String("pipeline"), c.String("step"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		utils.Highlight("==> %s\n", filepath.Base(file))
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(os.Stdout, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func handlePlan(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos := []string(c.Args())
+	if len(repos) == 0 {
+		repos, err = getSortedNames(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Planning terraform for [%s]\n\n", strings.Join(repos, ", "))
+	planned := 0
+	for _, repo := range repos {
+		execution, err := executor.GetExecution(filepath.Join(root, repo), "deploy")
+		if err != nil {
+			return err
+		}
+
+		plan, err := execution.Plan(os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		if plan == nil {
+			utils.Success("no terraform changes for %s\n", repo)
+			continue
+		}
+
+		planned++
+		utils.Success("saved plan for %s to %s\n", repo, executor.PlansDir(root, repo))
+	}
+
+	if planned > 0 {
+		utils.Highlight("\n==> Review the summary.txt of each plan, then run `plural deploy --plans` to apply exactly these plans\n")
+	}
+	return nil
+}
@@ -20,6 +20,24 @@ func main() {
 	app.Name = "plural"
 	app.Usage = "Tooling to manage your installed plural applications"
 	app.EnableBashCompletion = true
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "env",
+			Usage:  "the environment of the workspace to act on, see `plural env`",
+			EnvVar: "PLURAL_ENV",
+		},
+		cli.BoolFlag{
+			Name:  "local-lock",
+			Usage: "take a local deploy lock instead of the workspace's configured lock, eg before its cluster exists",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		localLock = c.GlobalBool("local-lock")
+		if err := selectEnv(c); err != nil {
+			return err
+		}
+		return pinTools(c)
+	}
 
 	if os.Getenv("ENABLE_COLOR") != "" {
 		color.NoColor = false
@@ -45,6 +63,10 @@ func main() {
 					Name: "force",
 					Usage: "force workspace to build even if remote is out of sync",
 				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "rebuild entirely from the local cache of a previous build, without network access",
+				},
 			},
 			Action: build,
 		},
@@ -74,15 +96,121 @@ func main() {
 					Name:  "force",
 					Usage: "use force push when pushing to git",
 				},
+				cli.IntFlag{
+					Name:  "parallelism",
+					Usage: "number of independent repos to deploy at the same time",
+					Value: 1,
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "print which steps would run without executing anything",
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "writes a json report of every step run to this file",
+				},
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "repo to resume the deploy from (useful when restarting failed deploys)",
+				},
+				cli.StringFlag{
+					Name:  "step",
+					Usage: "step within the --from repo to resume at, it will be run even if nothing changed",
+				},
+				cli.StringFlag{
+					Name:  "only-step",
+					Usage: "only (re)run this step, eg terraform-apply or bounce, in every repo",
+				},
+				cli.BoolFlag{
+					Name:  "plans",
+					Usage: "apply the terraform plans saved by `plural plan`, refusing if the terraform changed since",
+				},
 			},
 			Action: deploy,
 		},
+		{
+			Name:      "run",
+			Usage:     "runs the named pipeline, eg deploy, diff or any <name>.hcl in a repo, across the workspace",
+			ArgsUsage: "PIPELINE [REPO...]",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "parallelism",
+					Usage: "number of independent repos to run at the same time",
+					Value: 1,
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "print which steps would run without executing anything",
+				},
+				cli.StringFlag{
+					Name:  "only-step",
+					Usage: "only (re)run this step in every repo",
+				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "writes a json report of every step run to this file",
+				},
+			},
+			Action: requireArgs(handleRun, []string{"PIPELINE"}),
+		},
+		{
+			Name:      "pipelines",
+			Usage:     "lists the pipelines defined in a repo",
+			ArgsUsage: "REPO",
+			Action:    requireArgs(handlePipelines, []string{"REPO"}),
+		},
+		{
+			Name:      "plan",
+			Usage:     "saves terraform plans for changed repos under .plural/plans, to be applied with `plural deploy --plans`",
+			ArgsUsage: "[REPO...]",
+			Action:    handlePlan,
+		},
+		{
+			Name:      "drift",
+			Usage:     "checks whether the live terraform and helm state of each repo has drifted from git, exiting with status 2 if it has",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "output format, either table or json",
+					Value: "table",
+				},
+			},
+			Action: handleDrift,
+		},
+		{
+			Name:  "doctor",
+			Usage: "checks your tools, encryption setup, kube context and api access, with remediation for anything broken",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "output format, either table or json",
+					Value: "table",
+				},
+			},
+			Action: handleDoctor,
+		},
 		{
 			Name:      "diff",
 			Aliases:   []string{"df"},
 			Usage:     "diffs the state of  the current workspace with the deployed version and dumps results to diffs/",
 			ArgsUsage: "WKSPACE",
-			Action:    handleDiff,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "writes a json report of every step run to this file",
+				},
+				cli.StringFlag{
+					Name:  "summary",
+					Usage: "writes a summary of the terraform and helm changes, with secrets redacted, to this file",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format of the --summary file, either markdown or json",
+					Value: "markdown",
+				},
+			},
+			Action: handleDiff,
 		},
 		{
 			Name:      "create",
@@ -116,6 +244,22 @@ func main() {
 			Action:   apply,
 			Category: "Publishing",
 		},
+		{
+			Name:      "promote",
+			Usage:     "copies app versions and configuration from one workspace or environment to another, leaving environment specific values alone",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "path to the workspace to promote from, eg environments/staging",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "path to the workspace to promote to, eg environments/prod",
+				},
+			},
+			Action: handlePromote,
+		},
 		{
 			Name:    "validate",
 			Aliases: []string{"v"},
@@ -136,6 +280,12 @@ func main() {
 			Action:   topsort,
 			Category: "Workspace",
 		},
+		{
+			Name:        "deps",
+			Usage:       "Commands for inspecting the dependencies between the repos in your workspace",
+			Subcommands: depsCommands(),
+			Category:    "Workspace",
+		},
 		{
 			Name:      "bounce",
 			Aliases:   []string{"b"},
@@ -143,6 +293,18 @@ func main() {
 			ArgsUsage: "WKSPACE",
 			Action:    bounce,
 		},
+		{
+			Name:      "rollback",
+			Usage:     "rolls a repo back to a previously deployed release",
+			ArgsUsage: "REPO",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "to",
+					Usage: "release to roll back to, defaults to the one before the latest",
+				},
+			},
+			Action: requireArgs(handleRollback, []string{"REPO"}),
+		},
 		{
 			Name:      "destroy",
 			Aliases:   []string{"b"},
@@ -153,6 +315,10 @@ func main() {
 					Name:  "from",
 					Usage: "where to start your deploy command (useful when restarting interrupted destroys)",
 				},
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "writes a json report of every step run to this file",
+				},
 			},
 			Action: destroy,
 		},
@@ -248,6 +414,12 @@ func main() {
 			Subcommands: configCommands(),
 			Category:    "User Profile",
 		},
+		{
+			Name:        "env",
+			Usage:       "Commands for managing the environments (clusters) of your workspace",
+			Subcommands: envCommands(),
+			Category:    "Workspace",
+		},
 		{
 			Name:        "workspace",
 			Aliases:     []string{"wkspace"},
@@ -273,6 +445,30 @@ func main() {
 			Subcommands: logsCommands(),
 			Category:    "Debugging",
 		},
+		{
+			Name:      "history",
+			Usage:     "shows the ledger of deploys, destroys, bounces and builds run against this workspace",
+			ArgsUsage: "[REPO]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "since",
+					Usage: "only show entries since this duration ago (eg 72h) or date (eg 2021-06-01)",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "output format, either table or json",
+					Value: "table",
+				},
+			},
+			Action:   handleHistory,
+			Category: "Workspace",
+		},
+		{
+			Name:        "lock",
+			Usage:       "inspect or break the lock that stops concurrent deploys to a workspace",
+			Subcommands: lockCommands(),
+			Category:    "Workspace",
+		},
 		{
 			Name:        "bundle",
 			Usage:       "Commands for installing and discovering installation bundles",
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+func handlePromote(c *cli.Context) error {
+	if !c.IsSet("from") || !c.IsSet("to") {
+		return fmt.Errorf("both --from and --to are required")
+	}
+
+	from, err := filepath.Abs(c.String("from"))
+	if err != nil {
+		return err
+	}
+
+	to, err := filepath.Abs(c.String("to"))
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return fmt.Errorf("can't promote a workspace to itself")
+	}
+
+	promotions, err := manifest.PlanPromotion(from, to, []string(c.Args()))
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	terraform := []string{}
+	for _, p := range promotions {
+		if len(p.Terraform) > 0 {
+			terraform = append(terraform, p.Repo)
+		}
+		if len(p.Charts) == 0 && len(p.Config) == 0 {
+			continue
+		}
+
+		utils.Highlight("%s\n", p.Repo)
+		for _, change := range p.Charts {
+			fmt.Printf("  chart %s: %s => ", change.Chart, change.Current)
+			utils.Success("%s\n", change.Promoted)
+		}
+		for _, change := range p.Config {
+			if change.Skipped {
+				fmt.Printf("  config %s: left alone, it looks environment specific\n", change.Key)
+				continue
+			}
+			fmt.Printf("  config %s: %v => ", change.Key, change.Current)
+			utils.Success("%v\n", change.Promoted)
+		}
+		fmt.Println()
+
+		if !p.Empty() {
+			pending++
+		}
+	}
+
+	if len(terraform) > 0 {
+		utils.Warn("terraform for %s wasn't compared, manifest.yaml doesn't record terraform versions\n\n", strings.Join(terraform, ", "))
+	}
+
+	if pending == 0 {
+		utils.Success("%s is already up to date with %s\n", to, from)
+		return nil
+	}
+
+	if !confirm(fmt.Sprintf("Promote these changes into %d repos in %s?", pending, to)) {
+		return nil
+	}
+
+	if err := manifest.ApplyPromotions(to, promotions); err != nil {
+		return err
+	}
+
+	utils.Success("Promoted %s to %s, review the changes then build and deploy there\n", from, to)
+	fmt.Println("Promoted charts are pinned in each repo's manifest.yaml, remove `pinned: true` to follow the version installed in plural again")
+	return nil
+}
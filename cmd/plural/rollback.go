@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func handleRollback(c *cli.Context) error {
+	if err := validateOwner(); err != nil {
+		return err
+	}
+
+	repo := c.Args().Get(0)
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repoDir := filepath.Join(root, repo)
+	releases, err := executor.ReadReleases(repoDir)
+	if err != nil {
+		return err
+	}
+
+	release, err := executor.FindRelease(releases, c.Int("to"))
+	if err != nil {
+		return err
+	}
+
+	commit, err := release.ResolveSource(root, repo, releases)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Roll %s back to release %d (helm revision %d, files from %s) deployed %s?",
+		repo, release.Revision, release.HelmRevision, shortCommit(commit), release.Deployed.Local().Format("2006-01-02 15:04"))
+	if ok := confirm(msg); !ok {
+		return nil
+	}
+
+	l, err := acquireLock("rollback")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	// restore the files first, so if that fails the cluster is still in line with the repo
+	utils.Highlight("restoring %s/helm from %s\n", repo, shortCommit(commit))
+	if err := git.Restore(root, commit, filepath.Join(repo, "helm")); err != nil {
+		return err
+	}
+
+	conf := config.Read()
+	if release.HelmRevision > 0 {
+		utils.Highlight("helm rollback %s %d\n", repo, release.HelmRevision)
+		if err := utils.Cmd(&conf, "helm", "rollback", repo, strconv.Itoa(release.HelmRevision), "-n", conf.Namespace(repo)); err != nil {
+			return err
+		}
+	} else {
+		utils.Warn("no helm revision was recorded for release %d, skipping helm rollback\n", release.Revision)
+	}
+
+	rerun := false
+	prompt := &survey.Confirm{Message: fmt.Sprintf("Re-run terraform for %s from %s as well?", repo, shortCommit(commit)), Default: false}
+	survey.AskOne(prompt, &rerun)
+	if rerun {
+		if err := rollbackTerraform(root, repo, commit); err != nil {
+			return err
+		}
+	}
+
+	if err := restoreShas(root, repoDir, release); err != nil {
+		return err
+	}
+
+	utils.Success("rolled %s back to release %d, commit and push your changes to record it\n", repo, release.Revision)
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+func rollbackTerraform(root, repo, commit string) error {
+	tfDir := filepath.Join(root, repo, "terraform")
+	if err := git.Restore(root, commit, filepath.Join(repo, "terraform")); err != nil {
+		return err
+	}
+
+	if err := utils.CheckTool("terraform"); err != nil {
+		return err
+	}
+
+	for _, args := range [][]string{{"init", "-upgrade"}, {"apply", "-auto-approve"}} {
+		utils.Highlight("terraform %s\n", args[0])
+		cmd := exec.Command("terraform", args...)
+		cmd.Dir = tfDir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreShas resets the sha of every step whose target matches what was deployed in the
+// release, so the next deploy doesn't needlessly rerun it
+func restoreShas(root, repoDir string, release *executor.Release) error {
+	execution, err := executor.GetExecution(repoDir, "deploy")
+	if err != nil {
+		return err
+	}
+
+	ignore, err := execution.IgnoreFile(root)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range append(execution.Steps, execution.Hooks...) {
+		sha, ok := release.Steps[step.Name]
+		if !ok {
+			continue
+		}
+
+		if _, current, err := step.Changed(root, ignore); err == nil && current == sha {
+			step.Sha = sha
+		}
+	}
+
+	return execution.Flush(root)
+}
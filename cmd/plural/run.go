@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+func handleRun(c *cli.Context) (err error) {
+	if err := repoRoot(); err != nil {
+		return err
+	}
+
+	pipeline := c.Args().First()
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos, err := pipelineRepos(root, pipeline, c.Args().Tail())
+	if err != nil {
+		return err
+	}
+
+	d := &deployer{
+		repoRoot:    root,
+		pipeline:    pipeline,
+		parallelism: c.Int("parallelism"),
+		onlyStep:    c.String("only-step"),
+		report:      executor.NewReport(pipeline),
+	}
+
+	if c.Bool("dry-run") {
+		return d.dryRun(repos)
+	}
+
+	l, err := acquireLock("run " + pipeline)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	defer flushReport(d.report, c.String("report"))
+	defer func() { recordHistory(d.report, err) }()
+
+	waves, err := d.waves(repos)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Running %s for [%s] in topological order\n\n", pipeline, strings.Join(repos, ", "))
+	for _, wave := range waves {
+		if err := d.deployWave(wave); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pipelineRepos returns the requested repos, or every installed repo defining the pipeline, in topological order
+func pipelineRepos(root, pipeline string, requested []string) ([]string, error) {
+	if len(requested) > 0 {
+		for _, repo := range requested {
+			if !executor.HasPipeline(filepath.Join(root, repo), pipeline) {
+				return nil, fmt.Errorf("%s has no %s.hcl pipeline", repo, pipeline)
+			}
+		}
+		return wkspace.TopSortNames(requested)
+	}
+
+	sorted, err := allSortedRepos(api.NewClient())
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]string, 0)
+	for _, repo := range sorted {
+		if executor.HasPipeline(filepath.Join(root, repo), pipeline) {
+			repos = append(repos, repo)
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repo in this workspace defines a %s.hcl pipeline", pipeline)
+	}
+	return repos, nil
+}
+
+func handlePipelines(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	pipelines, err := executor.Pipelines(filepath.Join(root, c.Args().First()))
+	if err != nil {
+		return err
+	}
+
+	for _, pipeline := range pipelines {
+		fmt.Println(pipeline)
+	}
+	return nil
+}
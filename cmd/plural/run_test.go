@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPipelineReposSortsRequested(t *testing.T) {
+	root, err := ioutil.TempDir("", "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"bootstrap/manifest.yaml": "name: bootstrap\n",
+		"bootstrap/smoke.hcl":     "",
+		"postgres/manifest.yaml":  "name: postgres\ndependencies:\n- repo: bootstrap\n",
+		"postgres/smoke.hcl":      "",
+		"airflow/manifest.yaml":   "name: airflow\ndependencies:\n- repo: postgres\n",
+		"airflow/smoke.hcl":       "",
+	}
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := pipelineRepos(root, "smoke", []string{"airflow", "postgres", "bootstrap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"bootstrap", "postgres", "airflow"}; !reflect.DeepEqual(repos, expected) {
+		t.Errorf("pipelineRepos() = %v, expected %v", repos, expected)
+	}
+
+	if _, err := pipelineRepos(root, "missing", []string{"airflow"}); err == nil {
+		t.Errorf("expected an error for a repo without the pipeline")
+	}
+}
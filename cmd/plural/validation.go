@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/AlecAivazis/survey/v2"
@@ -53,6 +54,24 @@ func confirm(msg string) bool {
 	return res
 }
 
+// pinTools enforces the tool version constraints in workspace.yaml, if there is one
+func pinTools(c *cli.Context) error {
+	path := manifest.ProjectManifestPath()
+	if !utils.Exists(path) {
+		return nil
+	}
+
+	project, err := manifest.ReadProject(path)
+	if err != nil {
+		return nil
+	}
+
+	if err := utils.ConstrainTools(project.Tools); err != nil {
+		return fmt.Errorf("workspace.yaml is invalid: %s", err)
+	}
+	return nil
+}
+
 func repoRoot() error {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -41,6 +41,12 @@ func workspaceCommands() []cli.Command {
 			ArgsUsage: "NAME",
 			Action:    createCrds,
 		},
+		{
+			Name:      "crds-diff",
+			Usage:     "diffs the crds for this subworkspace against the live cluster",
+			ArgsUsage: "NAME",
+			Action:    diffCrds,
+		},
 	}
 }
 
@@ -78,6 +84,16 @@ func diffHelm(c *cli.Context) error {
 	return minimal.DiffHelm()
 }
 
+func diffCrds(c *cli.Context) error {
+	name := c.Args().Get(0)
+	minimal, err := wkspace.Minimal(name)
+	if err != nil {
+		return err
+	}
+
+	return minimal.DiffCrds()
+}
+
 func diffTerraform(c *cli.Context) error {
 	name := c.Args().Get(0)
 	minimal, err := wkspace.Minimal(name)
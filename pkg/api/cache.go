@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+// DiskCache persists installation metadata and package blobs under .plural/cache
+// so a workspace can be rebuilt without talking to the api.  Installations carry
+// license keys and oidc secrets, so the cache is never committed and only readable
+// by the current user
+type DiskCache struct {
+	Root    string
+	Offline bool
+}
+
+// NotCachedError is returned in offline mode when something was never fetched
+type NotCachedError struct {
+	What string
+}
+
+func (e *NotCachedError) Error() string {
+	return fmt.Sprintf("%s has not been cached yet, run `plural build` while online first", e.What)
+}
+
+var diskCache *DiskCache
+
+// EnableCache makes every client write through to the disk cache of the repo at root,
+// and if offline is set, serve exclusively from it
+func EnableCache(root string, offline bool) {
+	diskCache = &DiskCache{Root: filepath.Join(root, ".plural", "cache"), Offline: offline}
+}
+
+// Offline reports whether the api is being served from the disk cache
+func Offline() bool {
+	return diskCache != nil && diskCache.Offline
+}
+
+func (c *DiskCache) path(parts ...string) string {
+	return filepath.Join(append([]string{c.Root}, parts...)...)
+}
+
+func (c *DiskCache) read(what string, v interface{}, parts ...string) error {
+	contents, err := ioutil.ReadFile(c.path(parts...))
+	if os.IsNotExist(err) {
+		return &NotCachedError{What: what}
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(contents, v)
+}
+
+func (c *DiskCache) write(v interface{}, parts ...string) error {
+	contents, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.writeFile(contents, parts...)
+}
+
+func (c *DiskCache) writeFile(contents []byte, parts ...string) error {
+	path := c.path(parts...)
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, contents) {
+		return nil
+	}
+
+	if err := utils.MkIgnoredDir(c.Root); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// concurrent builds may share the cache, so swap files in atomically
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// cached serves v from the cache when offline, otherwise calls fetch and saves the result
+func cached(what string, v interface{}, fetch func() error, parts ...string) error {
+	if diskCache == nil {
+		return fetch()
+	}
+
+	if diskCache.Offline {
+		return diskCache.read(what, v, parts...)
+	}
+
+	if err := fetch(); err != nil {
+		return err
+	}
+
+	if err := diskCache.write(v, parts...); err != nil {
+		fmt.Fprintf(os.Stderr, "could not cache %s: %s\n", what, err)
+	}
+	return nil
+}
+
+// Download fetches the blob at url, which must be immutable for the given version
+// id, going through the disk cache if one is enabled
+func Download(url, versionId, name string) (io.ReadCloser, error) {
+	if diskCache == nil {
+		return get(url)
+	}
+
+	path := diskCache.path("packages", versionId, name)
+	if diskCache.Offline {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return nil, &NotCachedError{What: fmt.Sprintf("%s for version %s", name, versionId)}
+		}
+		return f, err
+	}
+
+	if f, err := os.Open(path); err == nil {
+		return f, nil
+	}
+
+	body, err := get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	contents, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := diskCache.writeFile(contents, "packages", versionId, name); err != nil {
+		fmt.Fprintf(os.Stderr, "could not cache %s: %s\n", name, err)
+	}
+	return ioutil.NopCloser(bytes.NewReader(contents)), nil
+}
+
+func get(url string) (io.ReadCloser, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Vendor saves a copy of the file at path under parts of the cache, if it exists and
+// the cache is enabled, so Restore can bring it back in an offline build
+func Vendor(path string, parts ...string) error {
+	if diskCache == nil || diskCache.Offline {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return diskCache.writeFile(contents, parts...)
+}
+
+// Restore copies a file saved by Vendor back to path, unless it's already there
+func Restore(path string, parts ...string) error {
+	if diskCache == nil || utils.Exists(path) {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(diskCache.path(parts...))
+	if os.IsNotExist(err) {
+		return &NotCachedError{What: filepath.Base(path)}
+	}
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(path, contents)
+}
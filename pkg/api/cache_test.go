@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michaeljguarino/graphql"
+	"github.com/pluralsh/plural/pkg/config"
+)
+
+const cacheTestResponse = `{"data": {
+	"installation": {"id": "i1", "licenseKey": "secret", "repository": {"id": "r1", "name": "airflow"}},
+	"installations": {"edges": [{"node": {"id": "i1", "licenseKey": "secret", "repository": {"id": "r1", "name": "airflow"}}}]},
+	"chartInstallations": {"edges": [{"node": {"id": "c1", "chart": {"id": "ch1", "name": "airflow"}, "version": {"id": "v1", "version": "0.1.0"}}}]},
+	"terraformInstallations": {"edges": []}
+}}`
+
+func withCache(t *testing.T, root string, offline bool) {
+	EnableCache(root, offline)
+	packageCache = make(map[string]*packageCacheEntry)
+	t.Cleanup(func() {
+		diskCache = nil
+		packageCache = make(map[string]*packageCacheEntry)
+	})
+}
+
+func TestOfflineBuildFromOnlineCache(t *testing.T) {
+	root, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tarball" {
+			fmt.Fprint(w, "terraform module")
+			return
+		}
+		fmt.Fprint(w, cacheTestResponse)
+	}))
+	client := &Client{graphql.NewClient(srv.URL + "/gql"), config.Config{}}
+
+	withCache(t, root, false)
+	if _, err := client.GetInstallations(); err != nil {
+		t.Fatalf("online GetInstallations: %s", err)
+	}
+	if _, err := client.GetInstallation("airflow"); err != nil {
+		t.Fatalf("online GetInstallation: %s", err)
+	}
+	if _, _, err := client.GetPackageInstallations("r1"); err != nil {
+		t.Fatalf("online GetPackageInstallations: %s", err)
+	}
+	body, err := Download(srv.URL+"/tarball", "v1", "terraform.tgz")
+	if err != nil {
+		t.Fatalf("online Download: %s", err)
+	}
+	body.Close()
+
+	if contents, err := ioutil.ReadFile(filepath.Join(root, ".plural", "cache", ".gitignore")); err != nil || string(contents) != "*\n" {
+		t.Errorf("expected the cache to be gitignored, got %q (%v)", contents, err)
+	}
+
+	srv.Close()
+	withCache(t, root, true)
+
+	insts, err := client.GetInstallations()
+	if err != nil || len(insts) != 1 || insts[0].Repository.Name != "airflow" {
+		t.Fatalf("offline GetInstallations = %v, %v", insts, err)
+	}
+
+	inst, err := client.GetInstallation("airflow")
+	if err != nil || inst.LicenseKey != "secret" {
+		t.Fatalf("offline GetInstallation = %v, %v", inst, err)
+	}
+
+	charts, _, err := client.GetPackageInstallations("r1")
+	if err != nil || len(charts) != 1 || charts[0].Version.Version != "0.1.0" {
+		t.Fatalf("offline GetPackageInstallations = %v, %v", charts, err)
+	}
+
+	body, err = Download(srv.URL+"/tarball", "v1", "terraform.tgz")
+	if err != nil {
+		t.Fatalf("offline Download: %s", err)
+	}
+	contents, _ := ioutil.ReadAll(body)
+	body.Close()
+	if string(contents) != "terraform module" {
+		t.Errorf("offline Download = %q", contents)
+	}
+
+	if _, _, err := client.GetPackageInstallations("r2"); err == nil {
+		t.Error("expected a repo that was never fetched to fail offline")
+	} else if _, ok := err.(*NotCachedError); !ok {
+		t.Errorf("expected a NotCachedError, got %T: %s", err, err)
+	}
+}
+
+func TestVendorAndRestore(t *testing.T) {
+	root, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	archive := filepath.Join(root, "charts", "airflow-0.1.0.tgz")
+	if err := os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(archive, []byte("chart"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withCache(t, root, false)
+	if err := Vendor(archive, "charts", "airflow-0.1.0.tgz"); err != nil {
+		t.Fatalf("Vendor: %s", err)
+	}
+
+	os.Remove(archive)
+	withCache(t, root, true)
+	if err := Restore(archive, "charts", "airflow-0.1.0.tgz"); err != nil {
+		t.Fatalf("Restore: %s", err)
+	}
+	if contents, _ := ioutil.ReadFile(archive); string(contents) != "chart" {
+		t.Errorf("restored %q", contents)
+	}
+
+	if err := Restore(filepath.Join(root, "charts", "other-0.1.0.tgz"), "charts", "other-0.1.0.tgz"); err == nil {
+		t.Error("expected restoring an archive that was never vendored to fail")
+	}
+}
@@ -100,14 +100,18 @@ func (client *Client) GetCharts(repoId string) ([]*Chart, error) {
 }
 
 func (client *Client) GetVersions(chartId string) ([]*Version, error) {
-	var resp versionsResponse
-	req := client.Build(versionsQuery)
-	req.Var("id", chartId)
-	err := client.Run(req, &resp)
-	versions := make([]*Version, len(resp.Versions.Edges))
-	for i, edge := range resp.Versions.Edges {
-		versions[i] = edge.Node
-	}
+	var versions []*Version
+	err := cached("the versions of chart "+chartId, &versions, func() error {
+		var resp versionsResponse
+		req := client.Build(versionsQuery)
+		req.Var("id", chartId)
+		err := client.Run(req, &resp)
+		versions = make([]*Version, len(resp.Versions.Edges))
+		for i, edge := range resp.Versions.Edges {
+			versions[i] = edge.Node
+		}
+		return err
+	}, "api", "versions", chartId+".json")
 	return versions, err
 }
 
@@ -128,36 +132,40 @@ func (client *Client) GetPackageInstallations(repoId string) (charts []*ChartIns
 		return entry.Charts, entry.Terraform, nil
 	}
 
-	var resp struct {
-		ChartInstallations struct {
-			Edges []*ChartInstallationEdge
+	var entry packageCacheEntry
+	err = cached("the packages of repository "+repoId, &entry, func() error {
+		var resp struct {
+			ChartInstallations struct {
+				Edges []*ChartInstallationEdge
+			}
+			TerraformInstallations struct {
+				Edges []*TerraformInstallationEdge
+			}
 		}
-		TerraformInstallations struct {
-			Edges []*TerraformInstallationEdge
+
+		req := client.Build(packageInstallationsQuery)
+		req.Var("id", repoId)
+		if err := client.Run(req, &resp); err != nil {
+			return err
 		}
-	}
 
-	req := client.Build(packageInstallationsQuery)
-	req.Var("id", repoId)
-	err = client.Run(req, &resp)
+		entry.Charts = make([]*ChartInstallation, len(resp.ChartInstallations.Edges))
+		for i, edge := range resp.ChartInstallations.Edges {
+			entry.Charts[i] = edge.Node
+		}
+
+		entry.Terraform = make([]*TerraformInstallation, len(resp.TerraformInstallations.Edges))
+		for i, edge := range resp.TerraformInstallations.Edges {
+			entry.Terraform[i] = edge.Node
+		}
+		return nil
+	}, "api", "packages", repoId+".json")
 	if err != nil {
 		return
 	}
 
-	charts = make([]*ChartInstallation, len(resp.ChartInstallations.Edges))
-	for i, edge := range resp.ChartInstallations.Edges {
-		charts[i] = edge.Node
-	}
-
-	tfs = make([]*TerraformInstallation, len(resp.TerraformInstallations.Edges))
-	for i, edge := range resp.TerraformInstallations.Edges {
-		tfs[i] = edge.Node
-	}
-
-	if err == nil {
-		packageCache[repoId] = &packageCacheEntry{Charts: charts, Terraform: tfs}
-	}
-	
+	charts, tfs = entry.Charts, entry.Terraform
+	packageCache[repoId] = &entry
 	return
 }
 
@@ -51,9 +51,21 @@ func (client *Client) GetInstallation(name string) (inst *Installation, err erro
 	var resp struct {
 		Installation *Installation
 	}
-	req := client.Build(instQuery)
-	req.Var("name", name)
-	err = client.Run(req, &resp)
+	err = cached("installation "+name, &resp.Installation, func() error {
+		req := client.Build(instQuery)
+		req.Var("name", name)
+		return client.Run(req, &resp)
+	}, "api", "installations", name+".json")
+	if _, ok := err.(*NotCachedError); ok {
+		// it might still have been fetched along with every other installation
+		if insts, e := client.GetInstallations(); e == nil {
+			for _, i := range insts {
+				if i.Repository.Name == name {
+					return i, nil
+				}
+			}
+		}
+	}
 	inst = resp.Installation
 	return
 }
@@ -70,12 +82,16 @@ func (client *Client) GetInstallationById(id string) (inst *Installation, err er
 }
 
 func (client *Client) GetInstallations() ([]*Installation, error) {
-	var resp instResponse
-	err := client.Run(client.Build(instsQuery), &resp)
-	insts := make([]*Installation, len(resp.Installations.Edges))
-	for i, edge := range resp.Installations.Edges {
-		insts[i] = edge.Node
-	}
+	var insts []*Installation
+	err := cached("the list of installations", &insts, func() error {
+		var resp instResponse
+		err := client.Run(client.Build(instsQuery), &resp)
+		insts = make([]*Installation, len(resp.Installations.Edges))
+		for i, edge := range resp.Installations.Edges {
+			insts[i] = edge.Node
+		}
+		return err
+	}, "api", "installations.json")
 	return insts, err
 }
 
@@ -278,6 +278,6 @@ func getAgePath() string {
 }
 
 func cryptPath() string {
-	root, _ := git.Root()
+	root, _ := git.TopLevel()
 	return filepath.Join(root, ".plural-crypt")
 }
\ No newline at end of file
@@ -0,0 +1,17 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Reset clears the output of a repo's previous diff, so only the steps that run this time
+// show up in diffs/<repo>
+func Reset(root, repo string) error {
+	path := filepath.Join(root, "diffs", repo)
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	return os.MkdirAll(path, os.ModePerm)
+}
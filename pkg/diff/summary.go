@@ -0,0 +1,377 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatJson     = "json"
+
+	// keep excerpts short enough for a pr comment
+	maxExcerpt = 20
+)
+
+var (
+	ansi         = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	tfResource   = regexp.MustCompile(`^\s*# (.+?) (?:is tainted, so )?(will be created|will be updated in-place|will be destroyed|must be replaced|will be replaced)`)
+	tfPlan       = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+	helmHeader   = regexp.MustCompile(`^(\S*), (\S+), (\S+) \(([^)]*)\) has (changed|been added|been removed):\s*$`)
+	yamlValue    = regexp.MustCompile(`^([+-]\s*(?:- )?[^:\s][^:]*:)\s+\S.*$`)
+	sensitiveKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|key|credential|private|cert)`)
+	nameKey      = regexp.MustCompile(`^(\s*(?:- )?)name:\s*["']?([^"'\s]*)`)
+	valueKey     = regexp.MustCompile(`^(\s*(?:- )?)value:`)
+	urlCreds     = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+):[^/\s@]+@`)
+	flagArg      = regexp.MustCompile(`(^|[\s"'])(--[a-zA-Z0-9][\w.-]*)(=|["']?,\s*["']?|\s+)("[^"]*"|'[^']*'|[^-\s"'][^\s"']*)`)
+	bareFlag     = regexp.MustCompile(`^(\s*)- ["']?(--[a-zA-Z0-9][\w.-]*)["']?\s*$`)
+	listItem     = regexp.MustCompile(`^(\s*)- `)
+	helmDiffLine = regexp.MustCompile(`^[+-]`)
+)
+
+type Summary struct {
+	Repos []*RepoSummary `json:"repos"`
+}
+
+type RepoSummary struct {
+	Repo      string            `json:"repo"`
+	Terraform *TerraformSummary `json:"terraform,omitempty"`
+	Helm      *HelmSummary      `json:"helm,omitempty"`
+	Crds      []string          `json:"crds,omitempty"`
+}
+
+type TerraformSummary struct {
+	Add       int      `json:"add"`
+	Change    int      `json:"change"`
+	Destroy   int      `json:"destroy"`
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Destroyed []string `json:"destroyed"`
+	Replaced  []string `json:"replaced"`
+}
+
+type HelmSummary struct {
+	Added   []*HelmResource `json:"added"`
+	Changed []*HelmResource `json:"changed"`
+	Removed []*HelmResource `json:"removed"`
+}
+
+type HelmResource struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Changes   []string `json:"changes,omitempty"`
+}
+
+// Summarize parses the terraform and helm output that `plural diff` left in diffs/ for each repo
+func Summarize(root string, repos []string) (*Summary, error) {
+	summary := &Summary{Repos: make([]*RepoSummary, 0, len(repos))}
+	for _, repo := range repos {
+		dir := filepath.Join(root, "diffs", repo)
+		repoSummary := &RepoSummary{Repo: repo}
+
+		tf, err := readDiff(filepath.Join(dir, "terraform"))
+		if err != nil {
+			return nil, err
+		}
+		if tf != "" {
+			repoSummary.Terraform = ParseTerraform(tf)
+		}
+
+		helm, err := readDiff(filepath.Join(dir, "helm"))
+		if err != nil {
+			return nil, err
+		}
+		if helm != "" {
+			repoSummary.Helm = ParseHelm(helm)
+		}
+
+		crds, err := readDiff(filepath.Join(dir, "crds"))
+		if err != nil {
+			return nil, err
+		}
+		repoSummary.Crds = ParseCrds(crds)
+
+		summary.Repos = append(summary.Repos, repoSummary)
+	}
+	return summary, nil
+}
+
+func readDiff(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return ansi.ReplaceAllString(string(contents), ""), nil
+}
+
+func ParseTerraform(out string) *TerraformSummary {
+	summary := &TerraformSummary{Created: []string{}, Updated: []string{}, Destroyed: []string{}, Replaced: []string{}}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := tfPlan.FindStringSubmatch(line); m != nil {
+			summary.Add, _ = strconv.Atoi(m[1])
+			summary.Change, _ = strconv.Atoi(m[2])
+			summary.Destroy, _ = strconv.Atoi(m[3])
+			continue
+		}
+
+		m := tfResource.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		switch m[2] {
+		case "will be created":
+			summary.Created = append(summary.Created, m[1])
+		case "will be updated in-place":
+			summary.Updated = append(summary.Updated, m[1])
+		case "will be destroyed":
+			summary.Destroyed = append(summary.Destroyed, m[1])
+		default:
+			summary.Replaced = append(summary.Replaced, m[1])
+		}
+	}
+	return summary
+}
+
+// ParseCrds returns the kind/name of every object with changes in the output of `plural wkspace crds-diff`
+func ParseCrds(out string) []string {
+	changed := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "+++ applied/") {
+			changed = append(changed, strings.TrimSpace(strings.TrimPrefix(line, "+++ applied/")))
+		}
+	}
+	return changed
+}
+
+func ParseHelm(out string) *HelmSummary {
+	summary := &HelmSummary{Added: []*HelmResource{}, Changed: []*HelmResource{}, Removed: []*HelmResource{}}
+	var current *HelmResource
+	var red *redactor
+	excerpt := false
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := helmHeader.FindStringSubmatch(line); m != nil {
+			current = &HelmResource{Namespace: m[1], Name: m[2], Kind: m[3]}
+			excerpt = m[5] == "changed"
+			red = newRedactor(current.Kind)
+			switch m[5] {
+			case "been added":
+				summary.Added = append(summary.Added, current)
+			case "been removed":
+				summary.Removed = append(summary.Removed, current)
+			default:
+				summary.Changed = append(summary.Changed, current)
+			}
+			continue
+		}
+
+		// only keep excerpts of in place changes, additions and removals are summarized by name
+		if !excerpt {
+			continue
+		}
+
+		// unchanged lines still name the env vars whose values change
+		red.observe(line)
+		if !helmDiffLine.MatchString(line) || len(current.Changes) >= maxExcerpt {
+			continue
+		}
+
+		current.Changes = append(current.Changes, red.redact(line))
+	}
+	return summary
+}
+
+// redactor blanks out every value of a secret, and any value whose key looks sensitive
+// elsewhere, including multiline block values like certs, the value of name/value pairs
+// like env vars with sensitive names, the value of command line flags with sensitive
+// names and the password of any url
+type redactor struct {
+	kind  string
+	block int
+	// indentation of the name key of the last name/value pair with a sensitive name, or -1
+	sensitiveName int
+	// indentation of the last list item holding just a sensitive flag, like - --password,
+	// whose value is the next item, or -1
+	sensitiveFlag int
+	// whether the line being redacted is the value of that flag
+	flagValue bool
+}
+
+func newRedactor(kind string) *redactor {
+	return &redactor{kind: kind, block: -1, sensitiveName: -1, sensitiveFlag: -1}
+}
+
+func (r *redactor) observe(line string) {
+	if line == "" {
+		return
+	}
+
+	body := line[1:]
+	if m := nameKey.FindStringSubmatch(body); m != nil {
+		r.sensitiveName = -1
+		if sensitiveKey.MatchString(m[2]) {
+			r.sensitiveName = len(m[1])
+		}
+	}
+
+	r.flagValue = false
+	if m := bareFlag.FindStringSubmatch(body); m != nil {
+		r.sensitiveFlag = -1
+		if sensitiveKey.MatchString(m[2]) {
+			r.sensitiveFlag = len(m[1])
+		}
+		return
+	}
+
+	if m := listItem.FindStringSubmatch(body); m != nil && len(m[1]) == r.sensitiveFlag {
+		r.flagValue = true
+		// a removed value is followed by the one replacing it
+		if line[0] == '-' {
+			return
+		}
+	}
+	r.sensitiveFlag = -1
+}
+
+func (r *redactor) redact(line string) string {
+	return urlCreds.ReplaceAllString(redactFlags(r.redactValue(line)), "$1:<redacted>@")
+}
+
+// redactFlags blanks the values of sensitive flags in line, like --password=x or --token x
+func redactFlags(line string) string {
+	matches := flagArg.FindAllStringSubmatchIndex(line, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		if sensitiveKey.MatchString(line[m[4]:m[5]]) {
+			line = line[:m[8]] + "<redacted>" + line[m[9]:]
+		}
+	}
+	return line
+}
+
+func (r *redactor) redactValue(line string) string {
+	sign, body := line[:1], line[1:]
+	indent := len(body) - len(strings.TrimLeft(body, " "))
+	if r.block >= 0 && indent > r.block {
+		return sign + " <redacted>"
+	}
+	r.block = -1
+
+	if r.flagValue {
+		return sign + listItem.FindString(body) + "<redacted>"
+	}
+
+	m := yamlValue.FindStringSubmatch(line)
+	if m == nil {
+		if r.kind == "Secret" && strings.TrimSpace(body) != "" {
+			return sign + " <redacted>"
+		}
+		return line
+	}
+
+	if r.kind != "Secret" && !sensitiveKey.MatchString(m[1]) && !r.sensitiveValue(body) {
+		return line
+	}
+
+	if value := strings.TrimSpace(line[len(m[1]):]); strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+		r.block = indent
+	}
+	return m[1] + " <redacted>"
+}
+
+// sensitiveValue reports whether body is the value of a name/value pair whose name is sensitive
+func (r *redactor) sensitiveValue(body string) bool {
+	m := valueKey.FindStringSubmatch(body)
+	return m != nil && r.sensitiveName >= 0 && len(m[1]) == r.sensitiveName
+}
+
+func (s *Summary) Render(format string) ([]byte, error) {
+	switch format {
+	case FormatJson:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(s)
+		return buf.Bytes(), err
+	case FormatMarkdown, "":
+		return s.markdown(), nil
+	default:
+		return nil, fmt.Errorf("unknown summary format %s, must be one of %s or %s", format, FormatMarkdown, FormatJson)
+	}
+}
+
+func (s *Summary) markdown() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("## Plural diff summary\n")
+	for _, repo := range s.Repos {
+		fmt.Fprintf(&buf, "\n### %s\n\n", repo.Repo)
+		if repo.Terraform == nil && repo.Helm == nil && len(repo.Crds) == 0 {
+			buf.WriteString("No diffs were generated.\n")
+			continue
+		}
+
+		if tf := repo.Terraform; tf != nil {
+			fmt.Fprintf(&buf, "**Terraform:** %d to add, %d to change, %d to destroy\n", tf.Add, tf.Change, tf.Destroy)
+			writeAddresses(&buf, "create", tf.Created)
+			writeAddresses(&buf, "update", tf.Updated)
+			writeAddresses(&buf, "replace", tf.Replaced)
+			writeAddresses(&buf, "destroy", tf.Destroyed)
+			buf.WriteString("\n")
+		}
+
+		if helm := repo.Helm; helm != nil {
+			fmt.Fprintf(&buf, "**Helm:** %d added, %d changed, %d removed\n", len(helm.Added), len(helm.Changed), len(helm.Removed))
+			writeResources(&buf, "added", helm.Added)
+			writeResources(&buf, "changed", helm.Changed)
+			writeResources(&buf, "removed", helm.Removed)
+			buf.WriteString("\n")
+		}
+
+		if len(repo.Crds) > 0 {
+			fmt.Fprintf(&buf, "**CRDs:** %d changed\n", len(repo.Crds))
+			writeAddresses(&buf, "change", repo.Crds)
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeAddresses(buf *bytes.Buffer, action string, addresses []string) {
+	for _, addr := range addresses {
+		fmt.Fprintf(buf, "- %s `%s`\n", action, addr)
+	}
+}
+
+func writeResources(buf *bytes.Buffer, action string, resources []*HelmResource) {
+	for _, r := range resources {
+		fmt.Fprintf(buf, "- %s %s `%s/%s`\n", action, r.Kind, r.Namespace, r.Name)
+		if len(r.Changes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "  <details><summary>changes</summary>\n\n  ```diff\n")
+		for _, line := range r.Changes {
+			fmt.Fprintf(buf, "  %s\n", line)
+		}
+		buf.WriteString("  ```\n  </details>\n")
+	}
+}
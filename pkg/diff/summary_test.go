@@ -0,0 +1,176 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTerraform(t *testing.T) {
+	out := `
+  # aws_s3_bucket.logs will be created
+  + resource "aws_s3_bucket" "logs" {
+  # aws_iam_role.node will be updated in-place
+  # aws_instance.old will be destroyed
+  # aws_db_instance.db must be replaced
+  # aws_instance.bad is tainted, so must be replaced
+
+Plan: 1 to add, 1 to change, 3 to destroy.
+`
+	expected := &TerraformSummary{
+		Add:       1,
+		Change:    1,
+		Destroy:   3,
+		Created:   []string{"aws_s3_bucket.logs"},
+		Updated:   []string{"aws_iam_role.node"},
+		Destroyed: []string{"aws_instance.old"},
+		Replaced:  []string{"aws_db_instance.db", "aws_instance.bad"},
+	}
+
+	if summary := ParseTerraform(out); !reflect.DeepEqual(summary, expected) {
+		t.Errorf("ParseTerraform() = %+v, expected %+v", summary, expected)
+	}
+}
+
+func TestParseCrds(t *testing.T) {
+	out := "--- live/Certificate/tls\n+++ applied/Certificate/tls\n@@ -1 +1 @@\n"
+	if crds := ParseCrds(out); !reflect.DeepEqual(crds, []string{"Certificate/tls"}) {
+		t.Errorf("ParseCrds() = %v", crds)
+	}
+}
+
+func TestParseHelm(t *testing.T) {
+	out := strings.Join([]string{
+		"airflow, web, Deployment (apps) has changed:",
+		"  spec:",
+		"-   replicas: 1",
+		"+   replicas: 2",
+		"airflow, web, Service (v1) has been added:",
+		"+ kind: Service",
+		"airflow, old, ConfigMap (v1) has been removed:",
+		"- kind: ConfigMap",
+	}, "\n")
+
+	summary := ParseHelm(out)
+	if len(summary.Added) != 1 || summary.Added[0].Kind != "Service" || len(summary.Added[0].Changes) != 0 {
+		t.Errorf("unexpected additions %+v", summary.Added)
+	}
+	if len(summary.Removed) != 1 || summary.Removed[0].Name != "old" {
+		t.Errorf("unexpected removals %+v", summary.Removed)
+	}
+
+	expected := &HelmResource{Namespace: "airflow", Name: "web", Kind: "Deployment", Changes: []string{"-   replicas: 1", "+   replicas: 2"}}
+	if len(summary.Changed) != 1 || !reflect.DeepEqual(summary.Changed[0], expected) {
+		t.Errorf("unexpected changes %+v", summary.Changed)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		diff     []string
+		expected []string
+	}{
+		{
+			name:     "secret data",
+			kind:     "Secret",
+			diff:     []string{"  data:", "-   password: b2xk", "+   password: bmV3"},
+			expected: []string{"-   password: <redacted>", "+   password: <redacted>"},
+		},
+		{
+			name:     "sensitive keys",
+			kind:     "ConfigMap",
+			diff:     []string{"  data:", "-   apiToken: old", "+   apiToken: new", "+   replicas: 2"},
+			expected: []string{"-   apiToken: <redacted>", "+   apiToken: <redacted>", "+   replicas: 2"},
+		},
+		{
+			name:     "block values",
+			kind:     "ConfigMap",
+			diff:     []string{"+   tls.cert: |", "+     -----BEGIN CERTIFICATE-----", "+     abcd", "+   other: value"},
+			expected: []string{"+   tls.cert: <redacted>", "+ <redacted>", "+ <redacted>", "+   other: value"},
+		},
+		{
+			name: "env var with a sensitive name",
+			kind: "Deployment",
+			diff: []string{
+				"          env:",
+				"          - name: DB_PASSWORD",
+				"-           value: old",
+				"+           value: hunter2",
+				"          - name: LOG_LEVEL",
+				"+           value: debug",
+			},
+			expected: []string{"-           value: <redacted>", "+           value: <redacted>", "+           value: debug"},
+		},
+		{
+			name: "changed env var name",
+			kind: "Deployment",
+			diff: []string{
+				"+         - name: API_SECRET",
+				"+           value: hunter2",
+			},
+			expected: []string{"+         - name: API_SECRET", "+           value: <redacted>"},
+		},
+		{
+			name:     "url credentials",
+			kind:     "ConfigMap",
+			diff:     []string{"  data:", "-   DATABASE_URL: postgres://u:supersecret@db/x", "+   DATABASE_URL: postgres://u:other@db/y"},
+			expected: []string{"-   DATABASE_URL: postgres://u:<redacted>@db/x", "+   DATABASE_URL: postgres://u:<redacted>@db/y"},
+		},
+		{
+			name:     "urls without credentials",
+			kind:     "ConfigMap",
+			diff:     []string{"+   HOMEPAGE: https://plural.sh/docs", "+   CALLBACK: https://user@plural.sh"},
+			expected: []string{"+   HOMEPAGE: https://plural.sh/docs", "+   CALLBACK: https://user@plural.sh"},
+		},
+		{
+			name: "sensitive flags",
+			kind: "Deployment",
+			diff: []string{
+				"          args:",
+				"-         - --db-password=old",
+				"+         - --db-password=new",
+				"+         - \"--api-token='hunter2'\"",
+				"+         - --log-level=debug",
+				"+         command: [\"server\", \"--secret\", \"hunter2\", \"--port\", \"8080\"]",
+				"+         - server --client-secret hunter2 --verbose",
+			},
+			expected: []string{
+				"-         - --db-password=<redacted>",
+				"+         - --db-password=<redacted>",
+				"+         - \"--api-token=<redacted>\"",
+				"+         - --log-level=debug",
+				"+         command: [\"server\", \"--secret\", \"<redacted>\", \"--port\", \"8080\"]",
+				"+         - server --client-secret <redacted> --verbose",
+			},
+		},
+		{
+			name: "sensitive flag values in the next arg",
+			kind: "Deployment",
+			diff: []string{
+				"          args:",
+				"          - --token",
+				"-         - old",
+				"+         - new",
+				"          - --port",
+				"+         - \"8080\"",
+			},
+			expected: []string{"-         - <redacted>", "+         - <redacted>", "+         - \"8080\""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := "airflow, resource, " + test.kind + " (v1) has changed:\n" + strings.Join(test.diff, "\n")
+			summary := ParseHelm(out)
+			if len(summary.Changed) != 1 {
+				t.Fatalf("expected one changed resource, got %+v", summary.Changed)
+			}
+
+			if changes := summary.Changed[0].Changes; !reflect.DeepEqual(changes, test.expected) {
+				t.Errorf("redacted to\n%s\nexpected\n%s", strings.Join(changes, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package environment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir is where environments live, relative to the root of the workspace repo.  Each
+// environment has its own workspace.yaml and context.yaml overlays over the shared
+// ones at the root, and its own set of repo directories
+const Dir = "environments"
+
+var current string
+
+// Select makes name the active environment, or clears it if name is empty
+func Select(name string) {
+	current = name
+}
+
+// Current returns the active environment, or "" if there isn't one
+func Current() string {
+	return current
+}
+
+// Path returns the root of the active environment under the workspace repo at toplevel,
+// which is just toplevel if no environment is active
+func Path(toplevel string) string {
+	if current == "" {
+		return toplevel
+	}
+	return filepath.Join(toplevel, Dir, current)
+}
+
+// Detect returns the environment containing dir, if any
+func Detect(toplevel, dir string) string {
+	rel, err := filepath.Rel(filepath.Join(toplevel, Dir), dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return strings.Split(rel, string(filepath.Separator))[0]
+}
+
+// List returns the names of every environment in the workspace repo at toplevel
+func List(toplevel string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(toplevel, Dir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	envs := []string{}
+	for _, f := range files {
+		if f.IsDir() {
+			envs = append(envs, f.Name())
+		}
+	}
+	return envs, nil
+}
+
+// Validate makes sure name is a usable environment name
+func Validate(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\.`) {
+		return fmt.Errorf("%q is not a valid environment name", name)
+	}
+	return nil
+}
@@ -60,3 +60,48 @@ func defaultSteps(path string) []*Step {
 		},
 	}
 }
+
+func defaultDiffSteps(path string) []*Step {
+	return []*Step{
+		{
+			Name:    "terraform-init",
+			Wkdir:   filepath.Join(path, "terraform"),
+			Target:  filepath.Join(path, "terraform"),
+			Command: "terraform",
+			Args:    []string{"init"},
+			Sha:     "",
+		},
+		{
+			Name:    "terraform",
+			Wkdir:   filepath.Join(path, "terraform"),
+			Target:  filepath.Join(path, "terraform"),
+			Command: "plural",
+			Args:    []string{"wkspace", "terraform-diff", path},
+			Sha:     "",
+		},
+		{
+			Name:    "kube-init",
+			Wkdir:   path,
+			Target:  pluralfile(path, "NONCE"),
+			Command: "plural",
+			Args:    []string{"wkspace", "kube-init", path},
+			Sha:     "",
+		},
+		{
+			Name:    "crds",
+			Wkdir:   path,
+			Target:  filepath.Join(path, "crds"),
+			Command: "plural",
+			Args:    []string{"wkspace", "crds-diff", path},
+			Sha:     "",
+		},
+		{
+			Name:    "helm",
+			Wkdir:   filepath.Join(path, "helm"),
+			Target:  filepath.Join(path, "helm"),
+			Command: "plural",
+			Args:    []string{"wkspace", "helm-diff", path},
+			Sha:     "",
+		},
+	}
+}
@@ -2,11 +2,14 @@ package executor
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/hcl"
+	"github.com/pluralsh/plural/pkg/environment"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/rodaine/hclencoder"
@@ -15,6 +18,10 @@ import (
 type Execution struct {
 	Metadata Metadata `hcl:"metadata"`
 	Steps    []*Step  `hcl:"step"`
+	Hooks    []*Step  `hcl:"hook" hcle:"omitempty"`
+	from     string   `hcle:"omit"`
+	only     string   `hcle:"omit"`
+	plans    bool     `hcle:"omit"`
 }
 
 type Metadata struct {
@@ -23,7 +30,8 @@ type Metadata struct {
 }
 
 const (
-	pluralIgnore = `terraform/.terraform`
+	pluralIgnore = `terraform/.terraform*
+.plural/logs`
 )
 
 func Ignore(root string) error {
@@ -48,95 +56,200 @@ func GetExecution(path, name string) (*Execution, error) {
 }
 
 func (e *Execution) Execute() error {
+	return e.ExecuteTo(os.Stdout)
+}
+
+// ExecuteTo runs every step of the execution, writing progress to out.  This
+// lets callers buffer the output of executions running concurrently.
+func (e *Execution) ExecuteTo(out io.Writer) error {
+	_, err := e.Run(out)
+	return err
+}
+
+// Run executes every step in order, returning a report of each step's outcome
+func (e *Execution) Run(out io.Writer) (*RepoReport, error) {
+	report := NewRepoReport(e.Metadata.Path)
 	root, err := git.Root()
 	if err != nil {
-		return err
+		report.Finish(err)
+		return report, err
 	}
 	ignore, err := e.IgnoreFile(root)
 
-	fmt.Printf("deploying %s, hold on to your butts\n", e.Metadata.Path)
-	for i, step := range e.Steps {
-		newSha, err := step.Execute(root, ignore)
+	steps, err := e.ordered()
+	if err != nil {
+		report.Finish(err)
+		return report, err
+	}
+
+	logs, err := newRunLogs(filepath.Join(root, e.Metadata.Path), e.Metadata.Name)
+	if err != nil {
+		fmt.Fprintf(out, "could not set up deploy logs: %s\n", err)
+	}
+	defer logs.prune()
+
+	if e.Metadata.Name == DeployPipeline {
+		fmt.Fprintf(out, "deploying %s, hold on to your butts\n", e.Metadata.Path)
+	} else {
+		fmt.Fprintf(out, "running %s for %s\n", e.Metadata.Name, e.Metadata.Path)
+	}
+	selected := e.selector()
+	for _, step := range steps {
+		skip, force := selected(step)
+		if skip {
+			continue
+		}
+
+		toRun := step
+		if e.plans && step.Name == applyStep {
+			toRun, err = e.planned(root, step, ignore, force)
+			if err != nil {
+				report.Finish(err)
+				return report, err
+			}
+		}
+
+		stepReport, err := toRun.run(out, root, ignore, runOptions{force: force, logs: logs})
+		report.AddStep(stepReport)
+		step.Failure = stepReport.Error
 		if err != nil {
+			report.Finish(err)
 			if err := e.Flush(root); err != nil {
-				return err
+				return report, err
 			}
 
-			return err
+			return report, err
 		}
 
-		e.Steps[i].Sha = newSha
+		step.Sha = stepReport.NewSha
+		if toRun != step {
+			// saved plans can only be applied once
+			ClearPlan(root, e.Metadata.Path)
+		}
 	}
 
-	return e.Flush(root)
-}
-
-func (e *Execution) IgnoreFile(root string) ([]string, error) {
-	ignorePath := filepath.Join(root, e.Metadata.Path, ".pluralignore")
-	contents, err := ioutil.ReadFile(ignorePath)
-	if err != nil {
-		return []string{}, err
+	err = e.Flush(root)
+	if err == nil && e.Metadata.Name == DeployPipeline && ranAny(report) {
+		if err := e.recordRelease(root); err != nil {
+			fmt.Fprintf(out, "could not record release of %s: %s\n", e.Metadata.Path, err)
+		}
 	}
+	report.Finish(err)
+	return report, err
+}
 
-	ignore := strings.Split(string(contents), "\n")
-	result := []string{}
-	for _, prefix := range ignore {
-		ignoreStr := strings.TrimSpace(prefix)
-		if ignoreStr != "" {
-			result = append(result, ignoreStr)
+func ranAny(report *RepoReport) bool {
+	for _, step := range report.Steps {
+		if step.Status == StatusRan {
+			return true
 		}
 	}
-
-	return result, nil
+	return false
 }
 
-func DefaultExecution(path string, prev *Execution) (e *Execution) {
-	byName := make(map[string]*Step)
-	steps := defaultSteps(path)
+// DryRun prints which steps would run and which would be skipped, without executing
+// anything or flushing new shas
+func (e *Execution) DryRun(out io.Writer) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+	ignore, _ := e.IgnoreFile(root)
 
-	for _, step := range prev.Steps {
-		byName[step.Name] = step
+	steps, err := e.ordered()
+	if err != nil {
+		return err
 	}
 
+	fmt.Fprintf(out, "planning %s\n", e.Metadata.Path)
+	selected := e.selector()
 	for _, step := range steps {
-		prev, ok := byName[step.Name]
-		if ok {
-			step.Sha = prev.Sha
+		skip, force := selected(step)
+		if skip {
+			continue
 		}
-		byName[step.Name] = step
-	}
 
-	// set up a topsort between the two orders of operations
-	graph := utils.Graph(len(byName))
-	for k := range byName {
-		graph.AddNode(k)
-	}
+		changed, _, err := step.Changed(root, ignore)
+		if err != nil {
+			return err
+		}
+
+		utils.Fhighlight(out, "%s %s ~> ", step.Command, strings.Join(step.Args, " "))
+		if force {
+			utils.Fhighlight(out, "would force %s to run\n", step.Name)
+			continue
+		}
 
-	for i := 0; i < len(steps)-1; i++ {
-		graph.AddEdge(steps[i].Name, steps[i+1].Name)
+		if !changed {
+			utils.Fsuccess(out, "no changes to be made for %s\n", step.Name)
+			continue
+		}
+
+		utils.Fhighlight(out, "would run %s\n", step.Name)
 	}
 
-	for i := 0; i < len(prev.Steps)-1; i++ {
-		graph.AddEdge(prev.Steps[i].Name, prev.Steps[i+1].Name)
+	return nil
+}
+
+// StartAt resumes the execution at the named step, forcing it to run even if its target is unchanged
+func (e *Execution) StartAt(step string) error {
+	if err := e.checkStep(step); err != nil {
+		return err
 	}
 
-	finalizedSteps := []*Step{}
-	sorted, ok := graph.Topsort()
-	if !ok {
-		panic("deployfile cycle detected")
+	e.from = step
+	return nil
+}
+
+// Only restricts the execution to the named step, forcing it to run
+func (e *Execution) Only(step string) error {
+	if err := e.checkStep(step); err != nil {
+		return err
 	}
 
-	// dump the topsort to a list and use that from now on
-	for _, name := range sorted {
-		finalizedSteps = append(finalizedSteps, byName[name])
+	e.only = step
+	return nil
+}
+
+func (e *Execution) checkStep(step string) error {
+	names := []string{}
+	for _, s := range append(append([]*Step{}, e.Steps...), e.Hooks...) {
+		if s.Name == step {
+			return nil
+		}
+		names = append(names, s.Name)
 	}
 
-	return &Execution{
-		Metadata: Metadata{Path: path, Name: "deploy"},
-		Steps:    finalizedSteps,
+	return fmt.Errorf("there is no step named %s in %s, it has %s", step, e.Metadata.Path, strings.Join(names, ", "))
+}
+
+// selector returns a function reporting whether each step (in order) should be
+// skipped given the resume options, and whether it must be forced to run
+func (e *Execution) selector() func(*Step) (bool, bool) {
+	started := e.from == ""
+	return func(step *Step) (bool, bool) {
+		if e.only != "" {
+			return step.Name != e.only, step.Name == e.only
+		}
+
+		if step.Name == e.from {
+			started = true
+			return false, true
+		}
+
+		return !started, false
 	}
 }
 
+func (e *Execution) IgnoreFile(root string) (*IgnoreMatcher, error) {
+	return ReadIgnore(filepath.Join(root, e.Metadata.Path))
+}
+
+// DefaultExecution merges the default deploy steps with any from a previous deploy.hcl
+func DefaultExecution(path string, prev *Execution) (*Execution, error) {
+	return DefaultPipeline(DeployPipeline, path, prev)
+}
+
 func (e *Execution) Flush(root string) error {
 	io, err := hclencoder.Encode(&e)
 	if err != nil {
@@ -147,6 +260,16 @@ func (e *Execution) Flush(root string) error {
 	return ioutil.WriteFile(path, io, 0644)
 }
 
+// encrypt makes sure files matching pattern under .plural, in the workspace or any
+// environment, are encrypted by git
+func encrypt(pattern string) error {
+	const attrs = "filter=plural-crypt diff=plural-crypt"
+	return git.EnsureAttributes(
+		fmt.Sprintf("%s %s", pattern, attrs),
+		fmt.Sprintf("/%s/*/%s %s", environment.Dir, pattern, attrs),
+	)
+}
+
 func pluralfile(base, name string) string {
 	return filepath.Join(base, ".plural", name)
 }
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	root, err := ioutil.TempDir("", "dryrun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	for _, dir := range []string{"helm", "terraform"} {
+		if err := os.MkdirAll(filepath.Join(root, "airflow", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(root, "airflow", dir, "main"), []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	step := func(name string) *Step {
+		return &Step{Name: name, Wkdir: "airflow", Target: "airflow/" + name, Command: "sh", Args: []string{"-c", "echo " + name + " >> ran"}}
+	}
+	ex := &Execution{Metadata: Metadata{Path: "airflow", Name: "deploy"}, Steps: []*Step{step("terraform"), step("helm")}}
+	if err := ex.ExecuteTo(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, "airflow", "terraform", "main"), []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sha := ex.Steps[0].Sha
+	out := &bytes.Buffer{}
+	if err := ex.DryRun(out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "would run terraform") || !strings.Contains(out.String(), "no changes to be made for helm") {
+		t.Errorf("unexpected dry run output\n%s", out)
+	}
+	if ran, _ := ioutil.ReadFile(filepath.Join(root, "airflow", "ran")); string(ran) != "terraform\nhelm\n" {
+		t.Errorf("expected the dry run not to run anything, but ran\n%s", ran)
+	}
+	if ex.Steps[0].Sha != sha {
+		t.Errorf("expected the dry run to leave the terraform sha alone")
+	}
+}
+
+func TestSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		only    string
+		skipped []bool
+		forced  []bool
+	}{
+		{name: "everything", skipped: []bool{false, false, false}, forced: []bool{false, false, false}},
+		{name: "from a step", from: "apply", skipped: []bool{true, false, false}, forced: []bool{false, true, false}},
+		{name: "only a step", only: "apply", skipped: []bool{true, false, true}, forced: []bool{false, true, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ex := &Execution{
+				Metadata: Metadata{Path: "airflow", Name: "deploy"},
+				Steps:    []*Step{{Name: "init"}, {Name: "apply"}, {Name: "bounce"}},
+			}
+			if test.from != "" {
+				if err := ex.StartAt(test.from); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.only != "" {
+				if err := ex.Only(test.only); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			selected := ex.selector()
+			skipped, forced := []bool{}, []bool{}
+			for _, step := range ex.Steps {
+				skip, force := selected(step)
+				skipped, forced = append(skipped, skip), append(forced, force)
+			}
+			if !reflect.DeepEqual(skipped, test.skipped) || !reflect.DeepEqual(forced, test.forced) {
+				t.Errorf("skipped %v and forced %v, expected %v and %v", skipped, forced, test.skipped, test.forced)
+			}
+		})
+	}
+
+	ex := &Execution{Metadata: Metadata{Path: "airflow"}, Steps: []*Step{{Name: "init"}, {Name: "apply"}}}
+	if err := ex.StartAt("missing"); err == nil {
+		t.Errorf("expected resuming from an unknown step to fail")
+	}
+	expected := "there is no step named missing in airflow, it has init, apply"
+	if err := ex.Only("missing"); err == nil || err.Error() != expected {
+		t.Errorf("expected running only an unknown step to fail listing the steps, got %v", err)
+	}
+}
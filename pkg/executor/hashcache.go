@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+// files modified this recently might be rewritten within the same mtime tick, so their
+// hashes aren't cached (the same trick git uses for its index)
+const racyWindow = 2 * time.Second
+
+type hashEntry struct {
+	Size  int64  `json:"size"`
+	Mtime int64  `json:"mtime"`
+	Sha   string `json:"sha"`
+}
+
+// hashCache remembers the sha256 of files keyed by path, size and mtime, so unchanged
+// files don't need to be reread every time a step's target is hashed
+type hashCache struct {
+	path    string
+	mut     sync.Mutex
+	entries map[string]*hashEntry
+	dirty   bool
+}
+
+var (
+	cacheOnce   sync.Once
+	sharedCache *hashCache
+)
+
+func HashCachePath(root string) string {
+	return filepath.Join(pluralfile(root, "cache"), "hashes.json")
+}
+
+// workspaceHashCache lazily loads the cache for the current workspace, or returns nil
+// (meaning don't cache) outside of a git repo
+func workspaceHashCache() *hashCache {
+	cacheOnce.Do(func() {
+		root, err := git.Root()
+		if err != nil {
+			return
+		}
+
+		sharedCache = readHashCache(HashCachePath(root))
+	})
+	return sharedCache
+}
+
+func readHashCache(path string) *hashCache {
+	cache := &hashCache{path: path, entries: map[string]*hashEntry{}}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	// a corrupt cache is just thrown away
+	if err := json.Unmarshal(contents, &cache.entries); err != nil {
+		cache.entries = map[string]*hashEntry{}
+	}
+	return cache
+}
+
+// sum returns the hex sha256 of the file at path, rereading it only if it changed since it was cached
+func (c *hashCache) sum(path string) (string, error) {
+	if c == nil {
+		return sumFile(path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	c.mut.Lock()
+	entry, ok := c.entries[path]
+	c.mut.Unlock()
+	if ok && entry.Size == info.Size() && entry.Mtime == info.ModTime().UnixNano() {
+		return entry.Sha, nil
+	}
+
+	sha, err := sumFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if time.Since(info.ModTime()) > racyWindow {
+		c.entries[path] = &hashEntry{Size: info.Size(), Mtime: info.ModTime().UnixNano(), Sha: sha}
+		c.dirty = true
+	} else if ok {
+		delete(c.entries, path)
+		c.dirty = true
+	}
+	return sha, nil
+}
+
+// prune drops cached files under dir that no longer exist
+func (c *hashCache) prune(dir string, seen map[string]bool) {
+	if c == nil {
+		return
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	prefix := dir + string(filepath.Separator)
+	for path := range c.entries {
+		if strings.HasPrefix(path, prefix) && !seen[path] {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+}
+
+func (c *hashCache) save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	io, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.MkIgnoredDir(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+
+	// concurrent plural processes may share the cache, so swap it in atomically
+	tmp := fmt.Sprintf("%s.%d", c.path, os.Getpid())
+	if err := ioutil.WriteFile(tmp, io, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+func sumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hash1 computes exactly the same digest as dirhash.Hash1, but gets each file's sha from the cache
+func hash1(files []string, resolve func(string) string, cache *hashCache) (string, error) {
+	h := sha256.New()
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+	for _, file := range files {
+		if strings.Contains(file, "\n") {
+			return "", errors.New("dirhash: filenames with newlines are not supported")
+		}
+
+		sum, err := cache.sum(resolve(file))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s  %s\n", sum, file)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestCachedHashMatchesDirhash(t *testing.T) {
+	root, err := ioutil.TempDir("", "hashcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "terraform")
+	cachePath := filepath.Join(root, ".plural", "cache", "hashes.json")
+	write := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// old enough to be outside the racy window, so it gets cached
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main.tf", "resource {}")
+	write("modules/vpc/main.tf", "module {}")
+	write("outputs.tf", "output {}")
+
+	tests := []struct {
+		name   string
+		change func()
+		// files that are ignored, and so must be removed before comparing with dirhash
+		ignored []string
+	}{
+		{name: "fresh", change: func() {}},
+		{name: "cached", change: func() {}},
+		{name: "modified with the same size and a new mtime", change: func() {
+			write("main.tf", "resource []")
+			later := time.Now().Add(-time.Minute)
+			os.Chtimes(filepath.Join(dir, "main.tf"), later, later)
+		}},
+		{name: "added", change: func() { write("variables.tf", "variable {}") }},
+		{name: "deleted", change: func() { os.Remove(filepath.Join(dir, "outputs.tf")) }},
+		{name: "ignored", change: func() {
+			ioutil.WriteFile(filepath.Join(root, ".pluralignore"), []byte(pluralIgnore), 0644)
+			write(".terraform.lock.hcl", "provider {}")
+			write(".terraform/providers/aws", "binary")
+		}, ignored: []string{".terraform.lock.hcl", ".terraform"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.change()
+			ignore, err := ReadIgnore(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cache := readHashCache(cachePath)
+			sum, err := cachedHash(dir, ignore, cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range test.ignored {
+				os.RemoveAll(filepath.Join(dir, name))
+			}
+
+			expected, err := dirhash.HashDir(dir, filepath.Base(dir), dirhash.Hash1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum != expected {
+				t.Errorf("cached hash %s, dirhash %s", sum, expected)
+			}
+		})
+	}
+
+	if contents, err := ioutil.ReadFile(filepath.Join(root, ".plural", "cache", ".gitignore")); err != nil || string(contents) != "*\n" {
+		t.Errorf("expected the hash cache to be gitignored, got %q (%v)", contents, err)
+	}
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+// HistoryEntry is one line of the workspace's append-only deploy ledger
+type HistoryEntry struct {
+	User     string         `json:"user"`
+	Command  string         `json:"command"`
+	Started  time.Time      `json:"started"`
+	Duration float64        `json:"duration"`
+	Commit   string         `json:"commit"`
+	Result   string         `json:"result"`
+	Error    string         `json:"error,omitempty"`
+	Repos    []*HistoryRepo `json:"repos"`
+}
+
+type HistoryRepo struct {
+	Repo   string   `json:"repo"`
+	Status string   `json:"status"`
+	Steps  []string `json:"steps"`
+}
+
+func HistoryPath(root string) string {
+	return pluralfile(root, "history.jsonl")
+}
+
+// Record appends the outcome of this report to the ledger at the root of the workspace.  Only
+// the first call records anything, so it can be both deferred and called explicitly.
+func (r *Report) Record(root string, err error) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.recorded {
+		return nil
+	}
+	r.recorded = true
+
+	conf := config.Read()
+	commit, _ := git.Head(root)
+	entry := &HistoryEntry{
+		User:     conf.Email,
+		Command:  r.Command,
+		Started:  r.Started,
+		Duration: time.Since(r.Started).Seconds(),
+		Commit:   commit,
+		Result:   StatusSucceeded,
+		Repos:    make([]*HistoryRepo, 0, len(r.Repos)),
+	}
+
+	if err != nil {
+		entry.Result = StatusFailed
+		entry.Error = err.Error()
+	}
+
+	for _, repo := range r.Repos {
+		ran := []string{}
+		for _, step := range repo.Steps {
+			if step.Status != StatusSkipped {
+				ran = append(ran, step.Name)
+			}
+		}
+		entry.Repos = append(entry.Repos, &HistoryRepo{Repo: repo.Repo, Status: repo.Status, Steps: ran})
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := encrypt(".plural/history.jsonl"); err != nil {
+		return err
+	}
+
+	path := HistoryPath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadHistory returns every ledger entry, oldest first
+func ReadHistory(root string) ([]*HistoryEntry, error) {
+	f, err := os.Open(HistoryPath(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*HistoryEntry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []*HistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		entry := &HistoryEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ordered merges any hooks into the execution's steps, placing each one directly
+// before or after the step it's anchored to.  Hooks without an anchor run last.
+func (e *Execution) ordered() ([]*Step, error) {
+	if len(e.Hooks) == 0 {
+		return e.Steps, nil
+	}
+
+	byName := make(map[string]*Step)
+	for _, step := range append(append([]*Step{}, e.Steps...), e.Hooks...) {
+		if _, ok := byName[step.Name]; ok {
+			return nil, fmt.Errorf("%s has more than one step or hook named %s", e.Metadata.Path, step.Name)
+		}
+		byName[step.Name] = step
+	}
+
+	after := make(map[string][]*Step)
+	before := make(map[string][]*Step)
+	trailing := make([]*Step, 0)
+	for _, hook := range e.Hooks {
+		anchor := hook.anchor()
+		if hook.After != "" && hook.Before != "" {
+			return nil, fmt.Errorf("hook %s in %s can only set one of after or before", hook.Name, e.Metadata.Path)
+		}
+
+		if anchor == "" {
+			trailing = append(trailing, hook)
+			continue
+		}
+
+		if _, ok := byName[anchor]; !ok {
+			return nil, fmt.Errorf("hook %s in %s is anchored to unknown step %s", hook.Name, e.Metadata.Path, anchor)
+		}
+
+		if hook.After != "" {
+			after[anchor] = append(after[anchor], hook)
+		} else {
+			before[anchor] = append(before[anchor], hook)
+		}
+	}
+
+	for _, hook := range e.Hooks {
+		if hook.Wkdir == "" {
+			hook.Wkdir = e.Metadata.Path
+		}
+
+		if hook.Target == "" {
+			hook.Target = inheritedTarget(hook, byName, e.Metadata.Path)
+		}
+	}
+
+	result := make([]*Step, 0, len(byName))
+	seen := make(map[string]bool)
+	var visit func(*Step)
+	visit = func(step *Step) {
+		if seen[step.Name] {
+			return
+		}
+		seen[step.Name] = true
+
+		for _, hook := range before[step.Name] {
+			visit(hook)
+		}
+		result = append(result, step)
+		for _, hook := range after[step.Name] {
+			visit(hook)
+		}
+	}
+
+	for _, step := range e.Steps {
+		visit(step)
+	}
+	for _, hook := range trailing {
+		visit(hook)
+	}
+
+	// anything left over is only reachable through other hooks, so it must be in a cycle
+	for _, hook := range e.Hooks {
+		if !seen[hook.Name] {
+			return nil, fmt.Errorf("hook cycle detected in %s: %s", e.Metadata.Path, strings.Join(hookCycle(hook, byName), " -> "))
+		}
+	}
+
+	return result, nil
+}
+
+func (step *Step) anchor() string {
+	if step.After != "" {
+		return step.After
+	}
+
+	return step.Before
+}
+
+// inheritedTarget follows a hook's anchors to the first step with a target, so by
+// default a hook reruns whenever the step it's attached to would.  Unanchored hooks
+// watch the whole repo.
+func inheritedTarget(hook *Step, byName map[string]*Step, path string) string {
+	step := hook
+	for i := 0; i < len(byName); i++ {
+		if step.Target != "" {
+			return step.Target
+		}
+
+		next, ok := byName[step.anchor()]
+		if !ok {
+			break
+		}
+		step = next
+	}
+
+	return path
+}
+
+func hookCycle(hook *Step, byName map[string]*Step) []string {
+	path := []string{}
+	index := make(map[string]int)
+	for step := hook; step != nil; step = byName[step.anchor()] {
+		if i, ok := index[step.Name]; ok {
+			return append(path[i:], step.Name)
+		}
+
+		index[step.Name] = len(path)
+		path = append(path, step.Name)
+	}
+
+	return path
+}
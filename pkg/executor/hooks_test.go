@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderedHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		hooks    []*Step
+		expected []string
+		err      string
+	}{
+		{
+			name:     "after",
+			hooks:    []*Step{{Name: "migrate", After: "apply"}},
+			expected: []string{"init", "apply", "migrate", "bounce"},
+		},
+		{
+			name:     "before",
+			hooks:    []*Step{{Name: "backup", Before: "bounce"}},
+			expected: []string{"init", "apply", "backup", "bounce"},
+		},
+		{
+			name:     "unanchored run last",
+			hooks:    []*Step{{Name: "smoke"}},
+			expected: []string{"init", "apply", "bounce", "smoke"},
+		},
+		{
+			name:     "anchored to other hooks",
+			hooks:    []*Step{{Name: "seed", After: "migrate"}, {Name: "migrate", After: "bounce"}, {Name: "drain", Before: "migrate"}},
+			expected: []string{"init", "apply", "bounce", "drain", "migrate", "seed"},
+		},
+		{
+			name:  "unknown anchor",
+			hooks: []*Step{{Name: "migrate", After: "missing"}},
+			err:   "anchored to unknown step missing",
+		},
+		{
+			name:  "cycle",
+			hooks: []*Step{{Name: "a", After: "b"}, {Name: "b", Before: "c"}, {Name: "c", After: "a"}},
+			err:   "hook cycle detected in airflow: a -> b -> c -> a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ex := &Execution{
+				Metadata: Metadata{Path: "airflow", Name: "deploy"},
+				Steps:    []*Step{{Name: "init"}, {Name: "apply"}, {Name: "bounce"}},
+				Hooks:    test.hooks,
+			}
+
+			steps, err := ex.ordered()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{}
+			for _, step := range steps {
+				names = append(names, step.Name)
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("ordered() = %v, expected %v", names, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const ignoreFileName = ".pluralignore"
+
+// .pluralignore lines used to be prefix matches, so older defaults are read as the
+// pattern that ignores the same files, otherwise .terraform.lock.hcl (which only exists
+// on some machines) would change every terraform step's sha
+var legacyPatterns = map[string]string{
+	"terraform/.terraform": "terraform/.terraform*",
+}
+
+// builtinIgnores are never part of a step's hash, whatever .pluralignore says: the pipeline
+// files at the root of a repo are rewritten with new shas on every run, and .plural holds
+// releases, plans and logs written by deploys.  Otherwise steps watching the whole repo,
+// like unanchored hooks, would rerun every time
+var builtinIgnores = []string{"/*.hcl", "/.plural/"}
+
+// IgnoreMatcher applies gitignore style patterns from a repo's .pluralignore files,
+// including any nested in subdirectories, to the files used for change detection
+type IgnoreMatcher struct {
+	root    string
+	matcher gitignore.Matcher
+}
+
+// ReadIgnore parses every .pluralignore file beneath dir.  Missing files just mean
+// nothing is ignored.
+func ReadIgnore(dir string) (*IgnoreMatcher, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([]gitignore.Pattern, 0)
+	for _, pattern := range builtinIgnores {
+		patterns = append(patterns, gitignore.ParsePattern(pattern, nil))
+	}
+	m := &IgnoreMatcher{root: dir, matcher: gitignore.NewMatcher(patterns)}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != dir && (info.Name() == ".git" || m.Match(path, true)) {
+			return filepath.SkipDir
+		}
+
+		domain := m.split(path)
+		found, err := readPatterns(filepath.Join(path, ignoreFileName), domain)
+		if err != nil {
+			return err
+		}
+
+		if len(found) > 0 {
+			patterns = append(patterns, found...)
+			m.matcher = gitignore.NewMatcher(patterns)
+		}
+		return nil
+	})
+
+	return m, err
+}
+
+func readPatterns(path string, domain []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	patterns := make([]gitignore.Pattern, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if migrated, ok := legacyPatterns[line]; ok {
+			line = migrated
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+
+	return patterns, scanner.Err()
+}
+
+// Match reports whether the absolute path is ignored, paths outside the repo never are
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	return m.matcher.Match(strings.Split(filepath.ToSlash(rel), "/"), isDir)
+}
+
+func (m *IgnoreMatcher) split(path string) []string {
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || rel == "." {
+		return nil
+	}
+
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
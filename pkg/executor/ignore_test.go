@@ -0,0 +1,152 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{
+			name:    "default ignores the terraform lockfile",
+			files:   map[string]string{".pluralignore": pluralIgnore},
+			path:    "terraform/.terraform.lock.hcl",
+			ignored: true,
+		},
+		{
+			name:    "default ignores the terraform plugin dir",
+			files:   map[string]string{".pluralignore": pluralIgnore},
+			path:    "terraform/.terraform",
+			isDir:   true,
+			ignored: true,
+		},
+		{
+			name:    "legacy default still ignores the terraform lockfile",
+			files:   map[string]string{".pluralignore": "terraform/.terraform\n.plural/logs"},
+			path:    "terraform/.terraform.lock.hcl",
+			ignored: true,
+		},
+		{
+			name:  "default keeps terraform sources",
+			files: map[string]string{".pluralignore": pluralIgnore},
+			path:  "terraform/main.tf",
+		},
+		{
+			name:    "pipeline files are always ignored",
+			path:    "deploy.hcl",
+			ignored: true,
+		},
+		{
+			name:    "deploy state is always ignored",
+			path:    ".plural/releases.json",
+			ignored: true,
+		},
+		{
+			name: "only pipeline files at the root of the repo are ignored",
+			path: "terraform/.terraform.lock.hcl",
+		},
+		{
+			name:    "globs",
+			files:   map[string]string{".pluralignore": "*.bak"},
+			path:    "helm/values.yaml.bak",
+			ignored: true,
+		},
+		{
+			name:    "double star",
+			files:   map[string]string{".pluralignore": "helm/**/charts/*.tgz"},
+			path:    "helm/airflow/charts/postgres-0.1.0.tgz",
+			ignored: true,
+		},
+		{
+			name:  "negation",
+			files: map[string]string{".pluralignore": "*.bak\n!keep.bak"},
+			path:  "helm/keep.bak",
+		},
+		{
+			name:  "directory only patterns don't match files",
+			files: map[string]string{".pluralignore": "build/"},
+			path:  "build",
+		},
+		{
+			name:    "directory only patterns match directories",
+			files:   map[string]string{".pluralignore": "build/"},
+			path:    "build",
+			isDir:   true,
+			ignored: true,
+		},
+		{
+			name:    "nested files apply to their subdirectory",
+			files:   map[string]string{"helm/.pluralignore": "*.tmp"},
+			path:    "helm/airflow/values.tmp",
+			ignored: true,
+		},
+		{
+			name:  "nested files don't apply outside their subdirectory",
+			files: map[string]string{"helm/.pluralignore": "*.tmp"},
+			path:  "terraform/state.tmp",
+		},
+		{
+			name:  "comments are skipped",
+			files: map[string]string{".pluralignore": "# *.tf"},
+			path:  "terraform/main.tf",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "ignore")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			writeFiles(t, root, test.files)
+
+			m, err := ReadIgnore(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ignored := m.Match(filepath.Join(root, test.path), test.isDir); ignored != test.ignored {
+				t.Errorf("Match(%s) = %v, expected %v", test.path, ignored, test.ignored)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherOutsideRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeFiles(t, root, map[string]string{".pluralignore": "*"})
+
+	m, err := ReadIgnore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Match(filepath.Join(filepath.Dir(root), "other"), false) {
+		t.Error("paths outside the root should never be ignored")
+	}
+}
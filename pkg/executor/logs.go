@@ -0,0 +1,176 @@
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	logTimeFormat = "20060102T150405"
+	// number of deploy runs worth of logs kept per repo
+	logRetention = 10
+)
+
+// LogDir is where step logs for a repo are written, it's gitignored
+func LogDir(repoDir string) string {
+	return filepath.Join(repoDir, ".plural", "logs")
+}
+
+// PipelineLogDir is where a pipeline's step logs go, deploy logs live at the top level
+// and every other pipeline gets its own subdirectory
+func PipelineLogDir(repoDir, pipeline string) string {
+	if pipeline == DeployPipeline || pipeline == "" {
+		return LogDir(repoDir)
+	}
+	return filepath.Join(LogDir(repoDir), pipeline)
+}
+
+type runLogs struct {
+	dir   string
+	stamp string
+}
+
+func newRunLogs(repoDir, pipeline string) (*runLogs, error) {
+	dir := PipelineLogDir(repoDir, pipeline)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(LogDir(repoDir), ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	return &runLogs{dir: dir, stamp: time.Now().UTC().Format(logTimeFormat)}, nil
+}
+
+func (l *runLogs) open(step string) (*os.File, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	path := filepath.Join(l.dir, fmt.Sprintf("%s-%s.log", l.stamp, step))
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// prune removes logs from all but the most recent runs
+func (l *runLogs) prune() error {
+	if l == nil {
+		return nil
+	}
+
+	logs, err := listLogs(l.dir)
+	if err != nil {
+		return err
+	}
+
+	stamps := runStamps(logs)
+	if len(stamps) <= logRetention {
+		return nil
+	}
+
+	expired := make(map[string]bool)
+	for _, stamp := range stamps[:len(stamps)-logRetention] {
+		expired[stamp] = true
+	}
+
+	for _, log := range logs {
+		if expired[log.stamp] {
+			os.Remove(filepath.Join(l.dir, log.file))
+		}
+	}
+	return nil
+}
+
+type stepLog struct {
+	file  string
+	stamp string
+	step  string
+	mod   time.Time
+}
+
+func listLogs(dir string) ([]*stepLog, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*stepLog, 0)
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".log") || len(name) <= len(logTimeFormat)+1 {
+			continue
+		}
+
+		stamp := name[:len(logTimeFormat)]
+		if _, err := time.Parse(logTimeFormat, stamp); err != nil {
+			continue
+		}
+
+		step := strings.TrimSuffix(name[len(logTimeFormat)+1:], ".log")
+		logs = append(logs, &stepLog{file: name, stamp: stamp, step: step, mod: f.ModTime()})
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].stamp != logs[j].stamp {
+			return logs[i].stamp < logs[j].stamp
+		}
+		return logs[i].mod.Before(logs[j].mod)
+	})
+	return logs, nil
+}
+
+func runStamps(logs []*stepLog) []string {
+	stamps := make([]string, 0)
+	for _, log := range logs {
+		if len(stamps) == 0 || stamps[len(stamps)-1] != log.stamp {
+			stamps = append(stamps, log.stamp)
+		}
+	}
+	return stamps
+}
+
+// LatestLogs returns the log files from the most recent deploy of a repo, in the order
+// the steps ran.  If step is set, only the most recent log for that step is returned.
+func LatestLogs(repoDir, step string) ([]string, error) {
+	return LatestPipelineLogs(repoDir, DeployPipeline, step)
+}
+
+// LatestPipelineLogs is LatestLogs for any pipeline
+func LatestPipelineLogs(repoDir, pipeline, step string) ([]string, error) {
+	dir := PipelineLogDir(repoDir, pipeline)
+	logs, err := listLogs(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no %s logs found in %s", pipeline, dir)
+		}
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	if step != "" {
+		for i := len(logs) - 1; i >= 0; i-- {
+			if logs[i].step == step {
+				return []string{filepath.Join(dir, logs[i].file)}, nil
+			}
+		}
+		return nil, fmt.Errorf("no %s logs found for step %s in %s", pipeline, step, dir)
+	}
+
+	stamps := runStamps(logs)
+	if len(stamps) == 0 {
+		return nil, fmt.Errorf("no %s logs found in %s", pipeline, dir)
+	}
+
+	latest := stamps[len(stamps)-1]
+	for _, log := range logs {
+		if log.stamp == latest {
+			result = append(result, filepath.Join(dir, log.file))
+		}
+	}
+	return result, nil
+}
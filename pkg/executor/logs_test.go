@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatestLogs(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+
+	dir := LogDir(repoDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// steps of a run share its stamp, and are ordered by when they were written
+	started := time.Now().Add(-time.Hour)
+	write := func(run int, steps ...string) {
+		stamp := started.Add(time.Duration(run) * time.Minute).UTC().Format(logTimeFormat)
+		for i, step := range steps {
+			path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", stamp, step))
+			if err := ioutil.WriteFile(path, []byte(step), 0644); err != nil {
+				t.Fatal(err)
+			}
+			mod := started.Add(time.Duration(run)*time.Minute + time.Duration(i)*time.Second)
+			if err := os.Chtimes(path, mod, mod); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for run := 0; run < logRetention+1; run++ {
+		write(run, "terraform-init", "terraform-apply")
+	}
+	write(logRetention+1, "helm", "bounce")
+
+	steps := func(paths []string) []string {
+		names := []string{}
+		for _, path := range paths {
+			contents, _ := ioutil.ReadFile(path)
+			names = append(names, string(contents))
+		}
+		return names
+	}
+
+	latest, err := LatestLogs(repoDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := steps(latest); !reflect.DeepEqual(names, []string{"helm", "bounce"}) {
+		t.Errorf("latest run logged %v", names)
+	}
+
+	// a single step's log comes from the last run that ran it
+	last := started.Add(time.Duration(logRetention) * time.Minute).UTC().Format(logTimeFormat)
+	apply, err := LatestLogs(repoDir, "terraform-apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, last+"-terraform-apply.log"); !reflect.DeepEqual(apply, []string{expected}) {
+		t.Errorf("LatestLogs(terraform-apply) = %v, expected %s", apply, expected)
+	}
+
+	if _, err := LatestLogs(repoDir, "missing"); err == nil {
+		t.Errorf("expected an error for a step without logs")
+	}
+
+	if err := (&runLogs{dir: dir}).prune(); err != nil {
+		t.Fatal(err)
+	}
+	logs, err := listLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stamps := runStamps(logs); len(stamps) != logRetention || stamps[0] != started.Add(2*time.Minute).UTC().Format(logTimeFormat) {
+		t.Errorf("expected only the last %d runs to be kept, got %v", logRetention, stamps)
+	}
+}
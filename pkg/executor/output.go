@@ -6,12 +6,17 @@ import (
 )
 
 type OutputWriter struct {
-	delegate    io.WriteCloser
+	delegate    io.Writer
 	useDelegate bool
 	lines       []string
+	log         io.Writer
 }
 
 func (out *OutputWriter) Write(line []byte) (int, error) {
+	if out.log != nil {
+		out.log.Write(line)
+	}
+
 	if out.useDelegate {
 		return out.delegate.Write(line)
 	}
@@ -22,7 +27,10 @@ func (out *OutputWriter) Write(line []byte) (int, error) {
 }
 
 func (out *OutputWriter) Close() error {
-	return out.delegate.Close()
+	if closer, ok := out.delegate.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func (out *OutputWriter) Format() string {
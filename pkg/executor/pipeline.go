@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const (
+	DeployPipeline = "deploy"
+	DiffPipeline   = "diff"
+)
+
+// the pipelines plural generates default steps for, any other <name>.hcl in a repo is
+// entirely user defined
+var builtinPipelines = map[string]func(path string) []*Step{
+	DeployPipeline: defaultSteps,
+	DiffPipeline:   defaultDiffSteps,
+}
+
+// HasPipeline reports whether the repo directory has a <name>.hcl pipeline
+func HasPipeline(repoDir, name string) bool {
+	return utils.Exists(filepath.Join(repoDir, name+".hcl"))
+}
+
+// Pipelines lists the names of every pipeline defined in a repo directory
+func Pipelines(repoDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(repoDir, "*.hcl"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		contents, err := ioutil.ReadFile(match)
+		if err != nil {
+			return nil, err
+		}
+
+		// other hcl files like build.hcl don't have the metadata/step shape of a pipeline
+		ex := Execution{}
+		if err := hcl.Decode(&ex, string(contents)); err != nil || ex.Metadata.Name == "" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".hcl"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DefaultPipeline merges the default steps of a builtin pipeline with any from its previous
+// <name>.hcl, keeping user-defined steps and hooks so they survive rebuilds
+func DefaultPipeline(name, path string, prev *Execution) (*Execution, error) {
+	defaults, ok := builtinPipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a builtin pipeline", name)
+	}
+
+	if prev == nil {
+		prev = &Execution{}
+	}
+
+	byName := make(map[string]*Step)
+	steps := defaults(path)
+
+	for _, step := range prev.Steps {
+		byName[step.Name] = step
+	}
+
+	for _, step := range steps {
+		prev, ok := byName[step.Name]
+		if ok {
+			step.keepSettings(prev)
+		}
+		byName[step.Name] = step
+	}
+
+	// set up a topsort between the two orders of operations
+	graph := utils.Graph(len(byName))
+	for k := range byName {
+		graph.AddNode(k)
+	}
+
+	for i := 0; i < len(steps)-1; i++ {
+		graph.AddEdge(steps[i].Name, steps[i+1].Name)
+	}
+
+	for i := 0; i < len(prev.Steps)-1; i++ {
+		graph.AddEdge(prev.Steps[i].Name, prev.Steps[i+1].Name)
+	}
+
+	finalizedSteps := []*Step{}
+	sorted, ok := graph.Topsort()
+	if !ok {
+		return nil, fmt.Errorf("cycle detected in the steps of %s/%s.hcl: %s", path, name, strings.Join(graph.Cycle(), " -> "))
+	}
+
+	// dump the topsort to a list and use that from now on
+	for _, step := range sorted {
+		finalizedSteps = append(finalizedSteps, byName[step])
+	}
+
+	e := &Execution{
+		Metadata: Metadata{Path: path, Name: name},
+		Steps:    finalizedSteps,
+		Hooks:    prev.Hooks,
+	}
+
+	if _, err := e.ordered(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// keepSettings carries the sha of a default step over from its previous definition,
+// along with anything a user tuned on it like timeouts, retries and env vars
+func (s *Step) keepSettings(prev *Step) {
+	s.Sha = prev.Sha
+	s.Retries = prev.Retries
+	if prev.Timeout != "" {
+		s.Timeout = prev.Timeout
+	}
+	if prev.RetryBackoff != "" {
+		s.RetryBackoff = prev.RetryBackoff
+	}
+	if prev.RetryDelay != "" {
+		s.RetryDelay = prev.RetryDelay
+	}
+	if len(prev.RetryOn) > 0 {
+		s.RetryOn = prev.RetryOn
+	}
+
+	for k, v := range prev.Env {
+		if s.Env == nil {
+			s.Env = map[string]string{}
+		}
+		s.Env[k] = v
+	}
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const customizedDeploy = `metadata {
+  path = "airflow"
+  name = "deploy"
+}
+
+step "terraform-apply" {
+  wkdir   = "airflow/terraform"
+  target  = "airflow/terraform"
+  command = "terraform"
+  args    = ["apply", "-auto-approve"]
+  sha     = "h1:applied"
+  retries = 3
+  timeout = "30m"
+  retry_backoff = "exponential"
+  retry_delay   = "10s"
+  retry_on      = ["Error acquiring the state lock"]
+  env = {
+    TF_LOG = "debug"
+  }
+}
+
+step "bounce" {
+  wkdir   = "airflow"
+  target  = "airflow/helm"
+  command = "plural"
+  args    = ["wkspace", "helm", "airflow"]
+  sha     = "h1:bounced"
+  retries = 1
+  timeout = "15m"
+}
+
+hook "migrate" {
+  wkdir   = "airflow"
+  target  = "airflow/helm"
+  command = "kubectl"
+  args    = ["apply", "-f", "migrate.yaml"]
+  sha     = ""
+  retries = 0
+  after   = "bounce"
+}
+`
+
+func TestRebuildKeepsCustomizedSteps(t *testing.T) {
+	root, err := ioutil.TempDir("", "pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "airflow")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "deploy.hcl"), []byte(customizedDeploy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// rebuild twice, to make sure flushed settings read back the same
+	for i := 0; i < 2; i++ {
+		prev, err := GetExecution(dir, "deploy")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ex, err := DefaultExecution("airflow", prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ex.Flush(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ex, err := GetExecution(dir, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := map[string]*Step{}
+	for _, step := range ex.Steps {
+		steps[step.Name] = step
+	}
+
+	apply := steps["terraform-apply"]
+	expected := &Step{
+		Name:         "terraform-apply",
+		Wkdir:        filepath.Join("airflow", "terraform"),
+		Target:       filepath.Join("airflow", "terraform"),
+		Command:      "terraform",
+		Args:         []string{"apply", "-auto-approve"},
+		Env:          map[string]string{"TF_LOG": "debug"},
+		Sha:          "h1:applied",
+		Retries:      3,
+		Timeout:      "30m",
+		RetryBackoff: "exponential",
+		RetryDelay:   "10s",
+		RetryOn:      []string{"Error acquiring the state lock"},
+	}
+	if !reflect.DeepEqual(apply, expected) {
+		t.Errorf("terraform-apply rebuilt as %+v, expected %+v", apply, expected)
+	}
+
+	if bounce := steps["bounce"]; bounce.Timeout != "15m" || bounce.Sha != "h1:bounced" || bounce.Retries != 1 {
+		t.Errorf("bounce rebuilt as %+v", bounce)
+	}
+
+	if init := steps["terraform-init"]; init.Timeout != "" || init.Env != nil || init.Sha != "" {
+		t.Errorf("untouched default steps should keep their defaults, got %+v", init)
+	}
+
+	if len(ex.Hooks) != 1 || ex.Hooks[0].Name != "migrate" || ex.Hooks[0].After != "bounce" {
+		t.Errorf("expected the migrate hook to be kept, got %+v", ex.Hooks)
+	}
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+const (
+	applyStep   = "terraform-apply"
+	planFile    = "terraform.tfplan"
+	summaryFile = "summary.txt"
+	planMeta    = "plan.json"
+)
+
+// Plan records a saved terraform plan, along with the hash of the terraform it was computed from
+type Plan struct {
+	Repo    string    `json:"repo"`
+	Step    string    `json:"step"`
+	Hash    string    `json:"hash"`
+	User    string    `json:"user"`
+	Created time.Time `json:"created"`
+}
+
+func PlansDir(root, repo string) string {
+	return filepath.Join(pluralfile(root, "plans"), repo)
+}
+
+func ReadPlan(root, repo string) (*Plan, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(PlansDir(root, repo), planMeta))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	plan := &Plan{}
+	if err := json.Unmarshal(contents, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func ClearPlan(root, repo string) error {
+	return os.RemoveAll(PlansDir(root, repo))
+}
+
+// UsePlans makes the terraform-apply step apply the plan saved by `plural plan` instead of
+// applying directly, refusing to run if the terraform changed since it was planned
+func (e *Execution) UsePlans() {
+	e.plans = true
+}
+
+// Plan runs terraform plan for the repo if its terraform has changed, saving the binary plan
+// and a human readable summary under .plural/plans.  It returns nil if there's nothing to plan.
+func (e *Execution) Plan(out io.Writer) (*Plan, error) {
+	root, err := git.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := e.IgnoreFile(root)
+	if err != nil {
+		return nil, err
+	}
+
+	step := e.step(applyStep)
+	if step == nil {
+		return nil, fmt.Errorf("%s has no %s step to plan", e.Metadata.Path, applyStep)
+	}
+
+	changed, current, err := step.Changed(root, ignore)
+	if err != nil || !changed {
+		return nil, err
+	}
+
+	// binary plans contain the values of every secret terraform touches
+	if err := encrypt(".plural/plans/**"); err != nil {
+		return nil, err
+	}
+
+	dir := PlansDir(root, e.Metadata.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckTool("terraform"); err != nil {
+		return nil, err
+	}
+
+	wkdir := filepath.Join(root, step.Wkdir)
+	planPath := filepath.Join(dir, planFile)
+	for _, args := range [][]string{{"init", "-upgrade"}, {"plan", "-input=false", "-out", planPath}} {
+		utils.Fhighlight(out, "terraform %s ~> ", args[0])
+		cmd, output := suppressedCommand(out, "terraform", args...)
+		cmd.Dir = wkdir
+		if err := runCommand(out, cmd, output); err != nil {
+			return nil, err
+		}
+	}
+
+	summary := exec.Command("terraform", "show", "-no-color", planPath)
+	summary.Dir = wkdir
+	res, err := summary.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, summaryFile), res, 0644); err != nil {
+		return nil, err
+	}
+
+	conf := config.Read()
+	plan := &Plan{Repo: e.Metadata.Path, Step: step.Name, Hash: current, User: conf.Email, Created: time.Now()}
+	io, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, ioutil.WriteFile(filepath.Join(dir, planMeta), io, 0644)
+}
+
+// planned swaps a changed terraform-apply step for one applying the saved plan, as long as
+// the terraform is exactly what was planned
+func (e *Execution) planned(root string, step *Step, ignore *IgnoreMatcher, force bool) (*Step, error) {
+	changed, current, err := step.Changed(root, ignore)
+	if err != nil || (!changed && !force) {
+		return step, err
+	}
+
+	plan, err := ReadPlan(root, e.Metadata.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan == nil {
+		return nil, fmt.Errorf("%s has terraform changes but no saved plan, run `plural plan %s` first", e.Metadata.Path, e.Metadata.Path)
+	}
+
+	if plan.Hash != current {
+		return nil, fmt.Errorf("the terraform in %s has changed since it was planned by %s at %s, run `plural plan %s` again",
+			e.Metadata.Path, plan.User, plan.Created.Local().Format(time.RFC1123), e.Metadata.Path)
+	}
+
+	applied := *step
+	applied.Args = []string{"apply", "-input=false", filepath.Join(PlansDir(root, e.Metadata.Path), planFile)}
+	return &applied, nil
+}
+
+func (e *Execution) step(name string) *Step {
+	for _, step := range e.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
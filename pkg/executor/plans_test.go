@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplySavedPlans(t *testing.T) {
+	root, err := ioutil.TempDir("", "plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	bin, err := ioutil.TempDir("", "terraform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+
+	// stands in for terraform, recording what it was asked to apply
+	applied := filepath.Join(bin, "applied")
+	terraform := filepath.Join(bin, "terraform")
+	if err := ioutil.WriteFile(terraform, []byte("#!/bin/sh\necho \"$@\" > "+applied+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "airflow", "terraform"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "airflow", "terraform", "main.tf"), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	step := &Step{Name: applyStep, Wkdir: "airflow/terraform", Target: "airflow/terraform", Command: terraform, Args: []string{"apply", "-auto-approve"}}
+	ex := &Execution{Metadata: Metadata{Path: "airflow", Name: "deploy"}, Steps: []*Step{step}}
+	ex.UsePlans()
+
+	if err := ex.ExecuteTo(ioutil.Discard); err == nil || !strings.Contains(err.Error(), "no saved plan") {
+		t.Fatalf("expected applying without a plan to fail, got %v", err)
+	}
+
+	ignore, err := ex.IgnoreFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, current, err := step.Changed(root, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savePlan := func(hash string) {
+		dir := PlansDir(root, "airflow")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		io, _ := json.Marshal(&Plan{Repo: "airflow", Step: applyStep, Hash: hash, User: "test@plural.sh", Created: time.Now()})
+		if err := ioutil.WriteFile(filepath.Join(dir, planMeta), io, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	savePlan("stale")
+	if err := ex.ExecuteTo(ioutil.Discard); err == nil || !strings.Contains(err.Error(), "changed since it was planned") {
+		t.Fatalf("expected applying a stale plan to fail, got %v", err)
+	}
+	if _, err := os.Stat(applied); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be applied")
+	}
+
+	savePlan(current)
+	if err := ex.ExecuteTo(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := ioutil.ReadFile(applied)
+	if expected := "apply -input=false " + filepath.Join(PlansDir(root, "airflow"), planFile) + "\n"; string(args) != expected {
+		t.Errorf("applied %q, expected %q", args, expected)
+	}
+	if plan, err := ReadPlan(root, "airflow"); plan != nil || err != nil {
+		t.Errorf("expected the plan to be cleared once applied, got %v (%v)", plan, err)
+	}
+	if step.Sha != current {
+		t.Errorf("expected the step's sha to be updated")
+	}
+}
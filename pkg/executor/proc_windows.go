@@ -0,0 +1,19 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// commands share our console, so they already get ctrl-c themselves
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) {}
+
+func terminateProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+// how many releases of each repo we keep around to roll back to
+const releaseRetention = 20
+
+// Release records the state of a repo after a successful deploy, so it can be rolled back to
+type Release struct {
+	Revision    int       `json:"revision"`
+	Deployed    time.Time `json:"deployed"`
+	Commit      string    `json:"commit"`
+	Uncommitted bool      `json:"uncommitted,omitempty"`
+	// Tree snapshots the workspace as deployed, and Trees holds the hash of each of the
+	// repo's restorable directories within it, to find the commit they ended up in
+	Tree         string            `json:"tree,omitempty"`
+	Trees        map[string]string `json:"trees,omitempty"`
+	HelmRevision int               `json:"helmRevision,omitempty"`
+	Steps        map[string]string `json:"steps"`
+}
+
+func ReleasesPath(repoDir string) string {
+	return pluralfile(repoDir, "releases.json")
+}
+
+// ReadReleases returns the recorded releases of a repo, oldest first
+func ReadReleases(repoDir string) ([]*Release, error) {
+	contents, err := ioutil.ReadFile(ReleasesPath(repoDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Release{}, nil
+		}
+		return nil, err
+	}
+
+	releases := []*Release{}
+	if err := json.Unmarshal(contents, &releases); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", ReleasesPath(repoDir), err)
+	}
+	return releases, nil
+}
+
+// FindRelease looks up a release by revision, where 0 means the one before the latest
+func FindRelease(releases []*Release, revision int) (*Release, error) {
+	if revision == 0 {
+		if len(releases) < 2 {
+			return nil, fmt.Errorf("there is no earlier release to roll back to")
+		}
+		return releases[len(releases)-2], nil
+	}
+
+	for _, release := range releases {
+		if release.Revision == revision {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find release %d", revision)
+}
+
+func writeReleases(repoDir string, releases []*Release) error {
+	if len(releases) > releaseRetention {
+		releases = releases[len(releases)-releaseRetention:]
+	}
+
+	io, err := json.MarshalIndent(releases, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ReleasesPath(repoDir)), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ReleasesPath(repoDir), io, 0644)
+}
+
+// recordRelease appends the repo's current helm revision, commit and step shas to its release history
+func (e *Execution) recordRelease(root string) error {
+	repoDir := filepath.Join(root, e.Metadata.Path)
+	releases, err := ReadReleases(repoDir)
+	if err != nil {
+		return err
+	}
+
+	release := &Release{Revision: 1, Deployed: time.Now(), Steps: map[string]string{}}
+	if len(releases) > 0 {
+		release.Revision = releases[len(releases)-1].Revision + 1
+	}
+
+	// the deploy itself usually isn't committed yet, so we note the base commit and resolve it on rollback
+	release.Commit, err = git.Head(root)
+	if err != nil {
+		return err
+	}
+	release.Uncommitted, err = git.HasChanges(root, e.Metadata.Path, ":(exclude)"+ReleasesPath(e.Metadata.Path))
+	if err != nil {
+		return err
+	}
+	if err := release.snapshot(root, e.Metadata.Path); err != nil {
+		return err
+	}
+
+	for _, step := range append(e.Steps, e.Hooks...) {
+		release.Steps[step.Name] = step.Sha
+	}
+
+	conf := config.Read()
+	release.HelmRevision, _ = HelmRevision(e.Metadata.Path, conf.Namespace(e.Metadata.Path))
+	return writeReleases(repoDir, append(releases, release))
+}
+
+// HelmRevision returns the currently deployed revision of a helm release
+func HelmRevision(name, namespace string) (int, error) {
+	cmd := exec.Command("helm", "status", name, "-n", namespace, "-o", "json")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	var status struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil {
+		return 0, err
+	}
+	return status.Version, nil
+}
+
+// RestorableDirs are the directories of a repo rollback restores
+var RestorableDirs = []string{"helm", "terraform"}
+
+// snapshot records exactly which files were deployed, since several deploys can run
+// on top of the same commit before any of them is committed
+func (r *Release) snapshot(root, repo string) error {
+	paths := make([]string, 0, len(RestorableDirs))
+	for _, dir := range RestorableDirs {
+		if path := filepath.Join(repo, dir); utils.Exists(filepath.Join(root, path)) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	tree, err := git.Snapshot(root, paths...)
+	if err != nil {
+		return err
+	}
+
+	r.Tree, r.Trees = tree, map[string]string{}
+	for _, path := range paths {
+		if sub, err := git.SubTree(root, tree, path); err == nil {
+			r.Trees[path] = sub
+		}
+	}
+	return nil
+}
+
+// ResolveSource finds a commit or tree holding a release's files.  That's its snapshot if it's
+// still around, otherwise its commit if it was deployed committed, or else the first commit
+// since holding exactly the files deployed.  Releases recorded without a snapshot can only be
+// resolved if no other uncommitted release was deployed on top of the same commit.
+func (r *Release) ResolveSource(root, repo string, releases []*Release) (string, error) {
+	if r.Commit == "" {
+		return "", fmt.Errorf("no commit was recorded for release %d of %s, so its files can't be restored", r.Revision, repo)
+	}
+
+	if r.Tree != "" && git.HasObject(root, r.Tree) {
+		return r.Tree, nil
+	}
+
+	if !r.Uncommitted {
+		return r.Commit, nil
+	}
+
+	if len(r.Trees) > 0 {
+		commit, err := git.CommitWithTrees(root, r.Commit, r.Trees)
+		if err != nil {
+			return "", err
+		}
+		if commit == "" {
+			return "", fmt.Errorf("the files of release %d of %s were never committed, so they can't be restored", r.Revision, repo)
+		}
+		return commit, nil
+	}
+
+	for _, other := range releases {
+		if other != r && other.Uncommitted && other.Commit == r.Commit {
+			return "", fmt.Errorf("releases %d and %d of %s were both deployed on top of commit %s before being committed, so their files can't be told apart", r.Revision, other.Revision, repo, r.Commit)
+		}
+	}
+
+	commit, err := git.FirstChangeSince(root, r.Commit, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if commit == "" {
+		return "", fmt.Errorf("release %d of %s was never committed, so its files can't be restored", r.Revision, repo)
+	}
+	return commit, nil
+}
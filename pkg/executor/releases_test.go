@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSource(t *testing.T) {
+	root, err := ioutil.TempDir("", "releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@plural.sh"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	values := filepath.Join(root, "airflow", "helm", "values.yaml")
+	write := func(contents string) {
+		if err := ioutil.WriteFile(values, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deploy := func(revision int) *Release {
+		release := &Release{Revision: revision, Commit: run("rev-parse", "HEAD"), Uncommitted: true}
+		if err := release.snapshot(root, "airflow"); err != nil {
+			t.Fatal(err)
+		}
+		return release
+	}
+	restored := func(source string) string {
+		contents, err := exec.Command("git", "-C", root, "show", source+":airflow/helm/values.yaml").Output()
+		if err != nil {
+			t.Fatalf("could not read values.yaml from %s: %s", source, err)
+		}
+		return string(contents)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(values), 0755); err != nil {
+		t.Fatal(err)
+	}
+	run("init")
+	write("v0")
+	run("add", ".")
+	run("commit", "-m", "base")
+
+	// two deploys on top of the same commit, neither committed yet
+	write("v1")
+	first := deploy(1)
+	write("v2")
+	second := deploy(2)
+	releases := []*Release{first, second}
+
+	for _, release := range releases {
+		source, err := release.ResolveSource(root, "airflow", releases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"", "v1", "v2"}[release.Revision]; restored(source) != expected {
+			t.Errorf("release %d resolved to files %q, expected %q", release.Revision, restored(source), expected)
+		}
+	}
+
+	// in another clone the snapshots aren't around, so the commit holding the same files is used
+	run("commit", "-am", "deploy v2")
+	first.Tree, second.Tree = "", ""
+	source, err := second.ResolveSource(root, "airflow", releases)
+	if err != nil || source != run("rev-parse", "HEAD") {
+		t.Errorf("expected release 2 to resolve to the commit of its files, got %s (%v)", source, err)
+	}
+	if _, err := first.ResolveSource(root, "airflow", releases); err == nil {
+		t.Errorf("expected release 1 to be unresolvable, since its files were never committed")
+	}
+
+	// releases recorded before snapshots can't be told apart
+	first.Trees, second.Trees = nil, nil
+	if _, err := first.ResolveSource(root, "airflow", releases); err == nil || !strings.Contains(err.Error(), "can't be told apart") {
+		t.Errorf("expected an ambiguity error, got %v", err)
+	}
+}
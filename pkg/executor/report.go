@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	StatusSkipped     = "skipped"
+	StatusRan         = "ran"
+	StatusFailed      = "failed"
+	StatusSucceeded   = "succeeded"
+	StatusInterrupted = "interrupted"
+)
+
+// Report is a machine readable record of a deploy, diff or destroy run, meant
+// for ci pipelines that wrap the cli
+type Report struct {
+	Command  string        `json:"command"`
+	Started  time.Time     `json:"started"`
+	Duration float64       `json:"duration"`
+	Repos    []*RepoReport `json:"repos"`
+	mut      sync.Mutex
+	recorded bool
+}
+
+type RepoReport struct {
+	Repo     string        `json:"repo"`
+	Status   string        `json:"status"`
+	Duration float64       `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Steps    []*StepReport `json:"steps"`
+	started  time.Time
+}
+
+type StepReport struct {
+	Name     string   `json:"name"`
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	PrevSha  string   `json:"previousSha"`
+	NewSha   string   `json:"newSha"`
+	Status   string   `json:"status"`
+	Duration float64  `json:"duration"`
+	Retries  int      `json:"retries"`
+	Error    string   `json:"error,omitempty"`
+	Output   string   `json:"output,omitempty"`
+	started  time.Time
+}
+
+func NewReport(command string) *Report {
+	return &Report{Command: command, Started: time.Now(), Repos: make([]*RepoReport, 0)}
+}
+
+// Add records a finished repo, it's safe to call from concurrent deploys
+func (r *Report) Add(repo *RepoReport) {
+	if repo == nil {
+		return
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.Repos = append(r.Repos, repo)
+}
+
+func (r *Report) Flush(path string) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.Duration = time.Since(r.Started).Seconds()
+	io, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, io, 0644)
+}
+
+func NewRepoReport(repo string) *RepoReport {
+	return &RepoReport{Repo: repo, Steps: make([]*StepReport, 0), started: time.Now()}
+}
+
+// AddStep records a step against this repo, it's a no-op on a nil report
+func (r *RepoReport) AddStep(step *StepReport) {
+	if r == nil || step == nil {
+		return
+	}
+
+	r.Steps = append(r.Steps, step)
+}
+
+func (r *RepoReport) Finish(err error) {
+	if r == nil {
+		return
+	}
+
+	r.Duration = time.Since(r.started).Seconds()
+	r.Status = StatusSucceeded
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+}
+
+func NewStepReport(name, command string, args ...string) *StepReport {
+	return &StepReport{Name: name, Command: command, Args: args, started: time.Now()}
+}
+
+func (s *StepReport) Skip() {
+	s.Status = StatusSkipped
+	s.Duration = time.Since(s.started).Seconds()
+}
+
+// Interrupt marks a step that was stopped by ctrl-c or SIGTERM rather than failing
+func (s *StepReport) Interrupt() {
+	s.Duration = time.Since(s.started).Seconds()
+	s.Status = StatusInterrupted
+}
+
+func (s *StepReport) Finish(err error) {
+	s.Duration = time.Since(s.started).Seconds()
+	s.Status = StatusRan
+	if err != nil {
+		s.Status = StatusFailed
+	}
+}
@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReportFlush(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := NewReport("deploy")
+	repo := NewRepoReport("airflow")
+	skipped := NewStepReport("terraform-init", "plural", "wkspace", "terraform-init", "airflow")
+	skipped.Skip()
+	failed := NewStepReport("helm", "plural", "wkspace", "helm", "airflow")
+	failed.Retries = 1
+	failed.Error = "exit status 1"
+	failed.Finish(fmt.Errorf("exit status 1"))
+	repo.AddStep(skipped)
+	repo.AddStep(failed)
+	repo.AddStep(nil)
+	repo.Finish(fmt.Errorf("exit status 1"))
+	report.Add(repo)
+	report.Add(nil)
+
+	// repos that never started are a no-op rather than a panic
+	var missing *RepoReport
+	missing.AddStep(skipped)
+	missing.Finish(nil)
+
+	path := filepath.Join(dir, "report.json")
+	if err := report.Flush(path); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Command string
+		Repos   []struct {
+			Repo   string
+			Status string
+			Error  string
+			Steps  []map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Command != "deploy" || len(parsed.Repos) != 1 {
+		t.Fatalf("unexpected report\n%s", contents)
+	}
+	if r := parsed.Repos[0]; r.Repo != "airflow" || r.Status != StatusFailed || r.Error != "exit status 1" || len(r.Steps) != 2 {
+		t.Fatalf("unexpected repo report\n%s", contents)
+	}
+
+	statuses := []interface{}{}
+	for _, step := range parsed.Repos[0].Steps {
+		statuses = append(statuses, step["status"])
+	}
+	if expected := []interface{}{StatusSkipped, StatusFailed}; !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("step statuses %v, expected %v", statuses, expected)
+	}
+	if step := parsed.Repos[0].Steps[1]; step["retries"] != float64(1) || step["error"] != "exit status 1" {
+		t.Errorf("unexpected failed step %v", step)
+	}
+	if _, ok := parsed.Repos[0].Steps[0]["error"]; ok {
+		t.Errorf("expected no error on the skipped step")
+	}
+}
@@ -0,0 +1,191 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+
+	defaultRetryDelay = 10 * time.Second
+	maxRetryDelay     = 5 * time.Minute
+	killGracePeriod   = 10 * time.Second
+)
+
+type retryPolicy struct {
+	timeout time.Duration
+	backoff string
+	delay   time.Duration
+	retryOn []*regexp.Regexp
+}
+
+func (step Step) retryPolicy() (*retryPolicy, error) {
+	policy := &retryPolicy{backoff: step.RetryBackoff}
+	if step.Timeout != "" {
+		timeout, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q for step %s: %s", step.Timeout, step.Name, err)
+		}
+		policy.timeout = timeout
+	}
+
+	switch step.RetryBackoff {
+	case "":
+	case BackoffFixed, BackoffExponential:
+		policy.delay = defaultRetryDelay
+	default:
+		return nil, fmt.Errorf("invalid retry_backoff %q for step %s, must be one of %s or %s", step.RetryBackoff, step.Name, BackoffFixed, BackoffExponential)
+	}
+
+	if step.RetryDelay != "" {
+		delay, err := time.ParseDuration(step.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry_delay %q for step %s: %s", step.RetryDelay, step.Name, err)
+		}
+		policy.delay = delay
+	}
+
+	for _, pattern := range step.RetryOn {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry_on pattern %q for step %s: %s", pattern, step.Name, err)
+		}
+		policy.retryOn = append(policy.retryOn, re)
+	}
+
+	return policy, nil
+}
+
+// retryable checks the failed command's output against the retry_on patterns, if there are any
+func (p *retryPolicy) retryable(output string) bool {
+	if len(p.retryOn) == 0 {
+		return true
+	}
+
+	for _, re := range p.retryOn {
+		if re.MatchString(output) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wait returns how long to sleep before the given retry attempt (starting at 1).
+// Exponential backoff stops doubling at maxRetryDelay
+func (p *retryPolicy) wait(attempt int) time.Duration {
+	if p.backoff != BackoffExponential {
+		return p.delay
+	}
+
+	wait := p.delay
+	for i := 1; i < attempt && wait < maxRetryDelay; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryDelay && p.delay < maxRetryDelay {
+		wait = maxRetryDelay
+	}
+	return wait
+}
+
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.timeout)
+}
+
+type interruptedError struct {
+	signal os.Signal
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("interrupted by %s", e.signal)
+}
+
+// runWithTimeout runs the command, killing its whole process group if it hasn't finished within
+// timeout.  If we're interrupted meanwhile, the interrupt is passed on and an *interruptedError
+// returned, so the step isn't retried
+func runWithTimeout(out io.Writer, cmd *exec.Cmd, output *OutputWriter, timeout time.Duration) error {
+	if timeout <= 0 {
+		return runCommand(out, cmd, output)
+	}
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(out, "\nOutput:\n\n%s\n", output.Format())
+		return err
+	}
+
+	stop := forwardSignals(cmd)
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		terminateProcessGroup(cmd)
+		select {
+		case <-done:
+		case <-time.After(killGracePeriod):
+			killProcessGroup(cmd)
+			<-done
+		}
+		err = &timeoutError{timeout: timeout}
+	}
+
+	if sig := stop(); sig != nil {
+		err = &interruptedError{signal: sig}
+	}
+
+	switch err.(type) {
+	case *timeoutError, *interruptedError:
+		fmt.Fprintf(out, "\n%s\nOutput:\n\n%s\n", err, output.Format())
+		return err
+	}
+	return reportResult(out, output, err)
+}
+
+// forwardSignals passes interrupts on to the command's process group, which being
+// separate from ours no longer gets them from the terminal.  Call the returned func
+// once the command has exited, it returns the last signal forwarded, if any
+func forwardSignals(cmd *exec.Cmd) func() os.Signal {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	forwarded := make(chan os.Signal, 1)
+	go func() {
+		var last os.Signal
+		for {
+			select {
+			case sig := <-sigs:
+				last = sig
+				signalProcessGroup(cmd, sig)
+			case <-done:
+				select {
+				case sig := <-sigs:
+					last = sig
+				default:
+				}
+				forwarded <- last
+				return
+			}
+		}
+	}()
+
+	return func() os.Signal {
+		signal.Stop(sigs)
+		close(done)
+		return <-forwarded
+	}
+}
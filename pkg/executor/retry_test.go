@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+func TestRetryWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "fixed", policy: retryPolicy{backoff: BackoffFixed, delay: time.Second}, attempt: 10, expected: time.Second},
+		{name: "first exponential", policy: retryPolicy{backoff: BackoffExponential, delay: time.Second}, attempt: 1, expected: time.Second},
+		{name: "doubles", policy: retryPolicy{backoff: BackoffExponential, delay: time.Second}, attempt: 4, expected: 8 * time.Second},
+		{name: "capped", policy: retryPolicy{backoff: BackoffExponential, delay: time.Second}, attempt: 20, expected: maxRetryDelay},
+		{name: "doesn't overflow", policy: retryPolicy{backoff: BackoffExponential, delay: 10 * time.Second}, attempt: 100, expected: maxRetryDelay},
+		{name: "long delays aren't shortened", policy: retryPolicy{backoff: BackoffExponential, delay: 10 * time.Minute}, attempt: 3, expected: 10 * time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if wait := test.policy.wait(test.attempt); wait != test.expected {
+				t.Errorf("wait(%d) = %s, expected %s", test.attempt, wait, test.expected)
+			}
+		})
+	}
+}
+
+const interruptHelperEnv = "PLURAL_INTERRUPT_HELPER"
+
+// TestInterruptHelper isn't a real test, TestInterruptedStepsArentRetried runs it in a
+// child process so it can be sent ctrl-c
+func TestInterruptHelper(t *testing.T) {
+	dir := os.Getenv(interruptHelperEnv)
+	if dir == "" {
+		t.Skip("only run by TestInterruptedStepsArentRetried")
+	}
+
+	script := `echo attempt >> attempts; trap 'echo interrupted >> attempts; kill $!; exit 3' INT; touch started; sleep 10 & wait`
+	step := Step{Name: "apply", Wkdir: ".", Target: "attempts", Command: "sh", Args: []string{"-c", script}, Retries: 1, Timeout: "1m"}
+	report, err := step.run(ioutil.Discard, dir, nil, runOptions{force: true})
+	fmt.Printf("status=%s retries=%d error=%v\n", report.Status, report.Retries, err)
+}
+
+func TestInterruptedStepsArentRetried(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands share the console on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "interrupt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "attempts"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	helper := exec.Command(os.Args[0], "-test.run=^TestInterruptHelper$", "-test.v")
+	helper.Env = append(os.Environ(), interruptHelperEnv+"="+dir)
+	helper.Stdout = &out
+	helper.Stderr = &out
+	if err := helper.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; !utils.Exists(filepath.Join(dir, "started")); i++ {
+		if i > 100 {
+			helper.Process.Kill()
+			t.Fatalf("the step never started: %s", out.String())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// only the helper is signalled, the step's process group has to get it from plural
+	helper.Process.Signal(os.Interrupt)
+	if err := helper.Wait(); err != nil {
+		t.Fatalf("helper failed: %s\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "status=interrupted retries=0 error=interrupted by interrupt") {
+		t.Errorf("expected the step to be reported as interrupted, got %s", out.String())
+	}
+	attempts, _ := ioutil.ReadFile(filepath.Join(dir, "attempts"))
+	if string(attempts) != "attempt\ninterrupted\n" {
+		t.Errorf("expected a single interrupted attempt, got %q", attempts)
+	}
+}
@@ -6,70 +6,208 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pluralsh/plural/pkg/utils"
 	"golang.org/x/mod/sumdb/dirhash"
 )
 
 type Step struct {
-	Name    string   `hcl:",key"`
-	Wkdir   string   `hcl:"wkdir"`
-	Target  string   `hcl:"target"`
-	Command string   `hcl:"command"`
-	Args    []string `hcl:"args"`
-	Sha     string   `hcl:"sha"`
-	Retries int      `hcl:"retries"`
+	Name         string            `hcl:",key"`
+	Wkdir        string            `hcl:"wkdir"`
+	Target       string            `hcl:"target"`
+	Command      string            `hcl:"command"`
+	Args         []string          `hcl:"args"`
+	Env          map[string]string `hcl:"env" hcle:"omitempty"`
+	Sha          string            `hcl:"sha"`
+	Retries      int               `hcl:"retries"`
+	Timeout      string            `hcl:"timeout" hcle:"omitempty"`
+	RetryBackoff string            `hcl:"retry_backoff" hcle:"omitempty"`
+	RetryDelay   string            `hcl:"retry_delay" hcle:"omitempty"`
+	RetryOn      []string          `hcl:"retry_on" hcle:"omitempty"`
+	Failure      string            `hcl:"failure" hcle:"omitempty"`
+
+	// hooks are anchored either after or before another step by name
+	After  string `hcl:"after" hcle:"omitempty"`
+	Before string `hcl:"before" hcle:"omitempty"`
 }
 
 func SuppressedCommand(command string, args ...string) (cmd *exec.Cmd, output *OutputWriter) {
+	return suppressedCommand(os.Stdout, command, args...)
+}
+
+func suppressedCommand(out io.Writer, command string, args ...string) (cmd *exec.Cmd, output *OutputWriter) {
 	cmd = exec.Command(command, args...)
-	output = &OutputWriter{delegate: os.Stdout}
+	output = &OutputWriter{delegate: out}
 	cmd.Stdout = output
 	cmd.Stderr = output
 	return
 }
 
 func RunCommand(cmd *exec.Cmd, output *OutputWriter) (err error) {
-	err = cmd.Run()
+	return runCommand(os.Stdout, cmd, output)
+}
+
+func runCommand(out io.Writer, cmd *exec.Cmd, output *OutputWriter) error {
+	return reportResult(out, output, cmd.Run())
+}
+
+func reportResult(out io.Writer, output *OutputWriter, err error) error {
 	if err != nil {
-		fmt.Printf("\nOutput:\n\n%s\n", output.Format())
-		return
+		fmt.Fprintf(out, "\nOutput:\n\n%s\n", output.Format())
+		return err
 	}
 
-	utils.Success("\u2713\n")
-	return
+	utils.Fsuccess(out, "\u2713\n")
+	return nil
 }
 
-func (step Step) Execute(root string, ignore []string) (string, error) {
-	current, err := MkHash(filepath.Join(root, step.Target), ignore)
+func (step Step) Execute(root string, ignore *IgnoreMatcher) (string, error) {
+	return step.ExecuteTo(os.Stdout, root, ignore)
+}
+
+// ExecuteTo runs the step, writing all progress output to out instead of stdout
+func (step Step) ExecuteTo(out io.Writer, root string, ignore *IgnoreMatcher) (string, error) {
+	report, err := step.Run(out, root, ignore)
+	return report.NewSha, err
+}
+
+// Run executes the step if its target has changed, returning a report of what was done
+func (step Step) Run(out io.Writer, root string, ignore *IgnoreMatcher) (*StepReport, error) {
+	return step.run(out, root, ignore, runOptions{})
+}
+
+type runOptions struct {
+	force bool
+	logs  *runLogs
+}
+
+func (step Step) run(out io.Writer, root string, ignore *IgnoreMatcher, opts runOptions) (*StepReport, error) {
+	report := NewStepReport(step.Name, step.Command, step.Args...)
+	report.PrevSha = step.Sha
+	report.NewSha = step.Sha
+
+	policy, err := step.retryPolicy()
+	if err != nil {
+		report.Error = err.Error()
+		report.Finish(err)
+		return report, err
+	}
+
+	changed, current, err := step.Changed(root, ignore)
 	if err != nil {
-		return step.Sha, err
+		report.Error = err.Error()
+		report.Finish(err)
+		return report, err
+	}
+
+	utils.Fhighlight(out, "%s %s ~> ", step.Command, strings.Join(step.Args, " "))
+	if !changed && !opts.force {
+		utils.Fsuccess(out, "no changes to be made for %s\n", step.Name)
+		report.NewSha = current
+		report.Skip()
+		return report, nil
 	}
 
-	utils.Highlight("%s %s ~> ", step.Command, strings.Join(step.Args, " "))
-	if current == step.Sha {
-		utils.Success("no changes to be made for %s\n", step.Name)
-		return current, nil
+	// steps mostly shell out to plural itself, so check the tools it'll end up running
+	if err := utils.CheckTools(); err != nil {
+		fmt.Fprintf(out, "\n")
+		report.Error = err.Error()
+		report.Finish(err)
+		return report, err
 	}
 
-	cmd, output := SuppressedCommand(step.Command, step.Args...)
-	cmd.Dir = filepath.Join(root, step.Wkdir)
-	err = RunCommand(cmd, output)
+	log, err := opts.logs.open(step.Name)
 	if err != nil {
-		if step.Retries > 0 {
-			step.Retries -= 1
-			fmt.Printf("retrying command, number of retries remaining: %d\n", step.Retries)
-			return step.Execute(root, ignore)
+		fmt.Fprintf(out, "could not open log file for %s: %s\n", step.Name, err)
+	}
+	if log != nil {
+		defer log.Close()
+	}
+
+	for {
+		cmd, output := suppressedCommand(out, step.Command, step.Args...)
+		cmd.Dir = filepath.Join(root, step.Wkdir)
+		if log != nil {
+			fmt.Fprintf(log, "==> %s %s (attempt %d)\n", step.Command, strings.Join(step.Args, " "), report.Retries+1)
+			output.log = log
+		}
+		cmd.Env = step.environ()
+		err = runWithTimeout(out, cmd, output, policy.timeout)
+		if log != nil {
+			fmt.Fprintf(log, "==> %s\n", resultMessage(err))
 		}
 
-		return step.Sha, err
+		if err == nil {
+			report.NewSha = current
+			report.Finish(nil)
+			return report, nil
+		}
+
+		if _, interrupted := err.(*interruptedError); interrupted {
+			report.Output = output.Format()
+			report.Error = err.Error()
+			report.Interrupt()
+			return report, err
+		}
+
+		if report.Retries >= step.Retries || !policy.retryable(output.Format()) {
+			report.Output = output.Format()
+			report.Error = err.Error()
+			report.Finish(err)
+			return report, err
+		}
+
+		report.Retries++
+		fmt.Fprintf(out, "retrying command, number of retries remaining: %d\n", step.Retries-report.Retries)
+		if wait := policy.wait(report.Retries); wait > 0 {
+			fmt.Fprintf(out, "waiting %s before retrying\n", wait)
+			time.Sleep(wait)
+		}
+		utils.Fhighlight(out, "%s %s ~> ", step.Command, strings.Join(step.Args, " "))
 	}
+}
 
-	return current, err
+func resultMessage(err error) string {
+	if err != nil {
+		return fmt.Sprintf("failed: %s", err)
+	}
+	return "succeeded"
 }
 
-func MkHash(root string, ignore []string) (string, error) {
+// environ layers the step's env on top of the current environment, or returns nil to
+// just inherit it
+func (step Step) environ() []string {
+	if len(step.Env) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(step.Env))
+	for k := range step.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	env := os.Environ()
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, step.Env[k]))
+	}
+	return env
+}
+
+// Changed computes the current hash of the step's target and reports whether it differs from the last recorded sha
+func (step Step) Changed(root string, ignore *IgnoreMatcher) (bool, string, error) {
+	current, err := MkHash(filepath.Join(root, step.Target), ignore)
+	if err != nil {
+		return false, step.Sha, err
+	}
+
+	return current != step.Sha, current, nil
+}
+
+func MkHash(root string, ignore *IgnoreMatcher) (string, error) {
 	fi, err := os.Stat(root)
 	if err != nil {
 		return "", err
@@ -83,7 +221,11 @@ func MkHash(root string, ignore []string) (string, error) {
 	}
 }
 
-func filteredHash(root string, ignore []string) (string, error) {
+func filteredHash(root string, ignore *IgnoreMatcher) (string, error) {
+	return cachedHash(root, ignore, workspaceHashCache())
+}
+
+func cachedHash(root string, ignore *IgnoreMatcher, cache *hashCache) (string, error) {
 	prefix := filepath.Base(root)
 	files, err := dirhash.DirFiles(root, prefix)
 	if err != nil {
@@ -91,28 +233,27 @@ func filteredHash(root string, ignore []string) (string, error) {
 	}
 
 	keep := []string{}
+	seen := map[string]bool{}
+	resolve := func(name string) string {
+		return filepath.Join(root, strings.TrimPrefix(name, prefix))
+	}
 	for _, file := range files {
-		trimmed := strings.TrimPrefix(file, root)
-		if ignorePath(trimmed, ignore) {
+		seen[resolve(file)] = true
+		if ignore.Match(resolve(file), false) {
 			continue
 		}
 
 		keep = append(keep, file)
 	}
 
-	osOpen := func(name string) (io.ReadCloser, error) {
-		return os.Open(filepath.Join(root, strings.TrimPrefix(name, prefix)))
+	sum, err := hash1(keep, resolve, cache)
+	if err != nil {
+		return "", err
 	}
 
-	return dirhash.Hash1(keep, osOpen)
-}
-
-func ignorePath(file string, ignore []string) bool {
-	for _, pref := range ignore {
-		if strings.HasPrefix(file, pref) {
-			return true
-		}
+	cache.prune(root, seen)
+	if err := cache.save(); err != nil {
+		utils.Warn("could not save the hash cache: %s\n", err)
 	}
-
-	return false
+	return sum, nil
 }
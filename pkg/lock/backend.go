@@ -0,0 +1,30 @@
+package lock
+
+import (
+	"fmt"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+)
+
+const (
+	BackendLocal   = "local"
+	BackendCluster = "cluster"
+)
+
+// ForWorkspace picks the backend configured by the `lock` field in workspace.yaml,
+// defaulting to a lock file in the repo root
+func ForWorkspace(root string) (Backend, error) {
+	man, err := manifest.FetchProject()
+	if err != nil {
+		return NewLocal(root), nil
+	}
+
+	switch man.Lock {
+	case "", BackendLocal:
+		return NewLocal(root), nil
+	case BackendCluster:
+		return NewCluster()
+	default:
+		return nil, fmt.Errorf("unknown lock backend %s in workspace.yaml, must be one of %s or %s", man.Lock, BackendLocal, BackendCluster)
+	}
+}
@@ -0,0 +1,201 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+const (
+	lockNamespace = "plural"
+	leaseName     = "plural-deploy-lock"
+
+	holderAnnotation  = "plural.sh/holder"
+	hostAnnotation    = "plural.sh/host"
+	commandAnnotation = "plural.sh/command"
+)
+
+// Cluster keeps the lease in a coordination.k8s.io Lease in the cluster itself, so
+// it's shared by everyone deploying to it
+type Cluster struct {
+	kube *utils.Kube
+}
+
+func NewCluster() (*Cluster, error) {
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cluster{kube: kube}, nil
+}
+
+func (c *Cluster) Name() string {
+	return "cluster"
+}
+
+func (c *Cluster) leases() coordinationclient.LeaseInterface {
+	return c.kube.Kube.CoordinationV1().Leases(lockNamespace)
+}
+
+func (c *Cluster) Acquire(lease *Lease) (*Lease, error) {
+	for attempt := 0; attempt < maxAcquireAttempts; attempt++ {
+		holder, retry, err := c.tryAcquire(lease)
+		if !retry {
+			return holder, err
+		}
+		time.Sleep(acquireRetryDelay)
+	}
+
+	return nil, fmt.Errorf("could not acquire the cluster lock, it kept changing hands")
+}
+
+// tryAcquire makes one attempt at taking the lease, retry is set if someone else
+// changed it in the meantime
+func (c *Cluster) tryAcquire(lease *Lease) (holder *Lease, retry bool, err error) {
+	ctx := context.Background()
+	existing, err := c.leases().Get(ctx, leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := c.ensureNamespace(ctx); err != nil {
+			return nil, false, err
+		}
+
+		_, err = c.leases().Create(ctx, toKube(lease, &coordinationv1.Lease{}), metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if current := fromKube(existing); current != nil && !current.Expired() && current.ID != lease.ID {
+		return current, false, nil
+	}
+
+	_, err = c.leases().Update(ctx, toKube(lease, existing), metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return nil, true, nil
+	}
+	return nil, false, err
+}
+
+func (c *Cluster) Renew(lease *Lease) error {
+	ctx := context.Background()
+	existing, err := c.leases().Get(ctx, leaseName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if current := fromKube(existing); current == nil || current.ID != lease.ID {
+		return fmt.Errorf("the cluster lock was broken by someone else")
+	}
+
+	_, err = c.leases().Update(ctx, toKube(lease, existing), metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Cluster) Release(lease *Lease) error {
+	ctx := context.Background()
+	existing, err := c.leases().Get(ctx, leaseName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if current := fromKube(existing); current == nil || current.ID != lease.ID {
+		return nil
+	}
+
+	precondition := metav1.Preconditions{ResourceVersion: &existing.ResourceVersion}
+	return c.leases().Delete(ctx, leaseName, metav1.DeleteOptions{Preconditions: &precondition})
+}
+
+func (c *Cluster) Get() (*Lease, error) {
+	existing, err := c.leases().Get(context.Background(), leaseName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return fromKube(existing), nil
+}
+
+func (c *Cluster) Break() error {
+	err := c.leases().Delete(context.Background(), leaseName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Cluster) ensureNamespace(ctx context.Context) error {
+	namespaces := c.kube.Kube.CoreV1().Namespaces()
+	if _, err := namespaces.Get(ctx, lockNamespace, metav1.GetOptions{}); err == nil {
+		return nil
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: lockNamespace}}
+	_, err := namespaces.Create(ctx, ns, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func toKube(lease *Lease, kl *coordinationv1.Lease) *coordinationv1.Lease {
+	duration := int32(leaseDuration.Seconds())
+	acquired := metav1.NewMicroTime(lease.Started)
+	renewed := metav1.NewMicroTime(lease.Expires.Add(-leaseDuration))
+
+	kl.Name = leaseName
+	kl.Namespace = lockNamespace
+	kl.Annotations = map[string]string{
+		holderAnnotation:  lease.Holder,
+		hostAnnotation:    lease.Host,
+		commandAnnotation: lease.Command,
+	}
+	kl.Spec = coordinationv1.LeaseSpec{
+		HolderIdentity:       &lease.ID,
+		LeaseDurationSeconds: &duration,
+		AcquireTime:          &acquired,
+		RenewTime:            &renewed,
+	}
+	return kl
+}
+
+func fromKube(kl *coordinationv1.Lease) *Lease {
+	spec := kl.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" {
+		return nil
+	}
+
+	lease := &Lease{
+		ID:      *spec.HolderIdentity,
+		Holder:  kl.Annotations[holderAnnotation],
+		Host:    kl.Annotations[hostAnnotation],
+		Command: kl.Annotations[commandAnnotation],
+	}
+
+	if spec.AcquireTime != nil {
+		lease.Started = spec.AcquireTime.Time
+	}
+
+	if spec.RenewTime != nil && spec.LeaseDurationSeconds != nil {
+		lease.Expires = spec.RenewTime.Time.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	}
+	return lease
+}
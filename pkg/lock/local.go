@@ -0,0 +1,152 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/environment"
+)
+
+const (
+	lockFile        = ".plural-lock"
+	takeoverTimeout = 30 * time.Second
+)
+
+// Ignored are the .gitignore lines keeping local lock files out of the workspace repo
+var Ignored = []string{"/" + lockFile + "*", "/" + environment.Dir + "/*/" + lockFile + "*"}
+
+// Local keeps the lease in a file at the root of the workspace repo, which protects
+// against concurrent deploys from the same checkout
+type Local struct {
+	path string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{path: filepath.Join(root, lockFile)}
+}
+
+func (l *Local) Name() string {
+	return "local"
+}
+
+func (l *Local) Acquire(lease *Lease) (*Lease, error) {
+	for attempt := 0; attempt < maxAcquireAttempts; attempt++ {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			defer f.Close()
+			return nil, json.NewEncoder(f).Encode(lease)
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		current, err := l.Get()
+		if err != nil {
+			return nil, err
+		}
+
+		if current != nil && !current.Expired() && current.ID != lease.ID {
+			return current, nil
+		}
+
+		if current != nil {
+			taken, err := l.takeOver(current, lease)
+			if taken || err != nil {
+				return nil, err
+			}
+		}
+		time.Sleep(acquireRetryDelay)
+	}
+
+	return nil, fmt.Errorf("could not acquire the lock at %s, it kept changing hands", l.path)
+}
+
+// takeOver replaces the expired lease stale with lease.  Takeovers are serialized by a
+// lock file of their own, and the lease is checked again while holding it, so two
+// deploys that both found it expired can't both take it
+func (l *Local) takeOver(stale, lease *Lease) (bool, error) {
+	guard := l.path + ".takeover"
+	f, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		// otherwise a takeover killed halfway would block the lock for good
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > takeoverTimeout {
+			os.Remove(guard)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	current, err := l.Get()
+	if err != nil || current == nil || current.ID != stale.ID || !current.Expires.Equal(stale.Expires) {
+		return false, err
+	}
+	return true, l.write(lease)
+}
+
+func (l *Local) Renew(lease *Lease) error {
+	current, err := l.Get()
+	if err != nil {
+		return err
+	}
+
+	if current == nil || current.ID != lease.ID {
+		return fmt.Errorf("the lock at %s was broken by someone else", l.path)
+	}
+
+	return l.write(lease)
+}
+
+func (l *Local) Release(lease *Lease) error {
+	current, err := l.Get()
+	if err != nil || current == nil || current.ID != lease.ID {
+		return err
+	}
+
+	return os.Remove(l.path)
+}
+
+func (l *Local) Get() (*Lease, error) {
+	contents, err := ioutil.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	lease := &Lease{}
+	if err := json.Unmarshal(contents, lease); err != nil {
+		return nil, fmt.Errorf("could not parse lock file %s: %s", l.path, err)
+	}
+	return lease, nil
+}
+
+func (l *Local) Break() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// write replaces the lock file atomically so readers never see a partial lease
+func (l *Local) write(lease *Lease) error {
+	io, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+
+	tmp := l.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, io, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
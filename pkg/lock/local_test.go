@@ -0,0 +1,114 @@
+package lock
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func testLease(id string, expires time.Time) *Lease {
+	return &Lease{ID: id, Holder: id + "@plural.sh", Command: "deploy", Started: time.Now(), Expires: expires}
+}
+
+func TestLocalLock(t *testing.T) {
+	root, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	local := NewLocal(root)
+	later := time.Now().Add(leaseDuration)
+	first, second := testLease("first", later), testLease("second", later)
+
+	if holder, err := local.Acquire(first); err != nil || holder != nil {
+		t.Fatalf("expected to take the free lock, got %v (%v)", holder, err)
+	}
+	if holder, err := local.Acquire(second); err != nil || holder == nil || holder.ID != "first" {
+		t.Fatalf("expected the lock to be held by first, got %v (%v)", holder, err)
+	}
+	if holder, err := local.Acquire(first); err != nil || holder != nil {
+		t.Errorf("expected the holder to be able to take the lock again, got %v (%v)", holder, err)
+	}
+
+	// someone else can neither renew nor release it
+	if err := local.Renew(second); err == nil {
+		t.Errorf("expected renewing someone else's lease to fail")
+	}
+	if err := local.Release(second); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := local.Get(); current == nil || current.ID != "first" {
+		t.Fatalf("expected releasing someone else's lease to leave it be, got %v", current)
+	}
+
+	// but it can be taken once it expires
+	first.Expires = time.Now().Add(-time.Second)
+	if err := local.Renew(first); err != nil {
+		t.Fatal(err)
+	}
+	if holder, err := local.Acquire(second); err != nil || holder != nil {
+		t.Fatalf("expected the expired lock to be taken over, got %v (%v)", holder, err)
+	}
+	if current, _ := local.Get(); current == nil || current.ID != "second" {
+		t.Fatalf("expected second to hold the lock, got %v", current)
+	}
+
+	if err := local.Release(second); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := local.Get(); current != nil || err != nil {
+		t.Errorf("expected the lock to be free, got %v (%v)", current, err)
+	}
+}
+
+func TestLocalTakeover(t *testing.T) {
+	root, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	local := NewLocal(root)
+	stale := testLease("stale", time.Now().Add(-time.Second))
+	if _, err := local.Acquire(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// both deployers saw the lease expire, but only the first to take over gets it
+	first, second := testLease("first", time.Now().Add(leaseDuration)), testLease("second", time.Now().Add(leaseDuration))
+	if taken, err := local.takeOver(stale, first); !taken || err != nil {
+		t.Fatalf("expected first to take over, got %v (%v)", taken, err)
+	}
+	if taken, err := local.takeOver(stale, second); taken || err != nil {
+		t.Fatalf("expected second to find the lease already taken over, got %v (%v)", taken, err)
+	}
+	if holder, err := local.Acquire(second); err != nil || holder == nil || holder.ID != "first" {
+		t.Fatalf("expected second to be told first holds the lock, got %v (%v)", holder, err)
+	}
+
+	// a takeover in progress holds others off, unless it was abandoned long ago
+	guard := local.path + ".takeover"
+	first.Expires = time.Now().Add(-time.Second)
+	if err := local.Renew(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(guard, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if taken, err := local.takeOver(first, second); taken || err != nil {
+		t.Fatalf("expected the takeover to wait for the one in progress, got %v (%v)", taken, err)
+	}
+
+	abandoned := time.Now().Add(-2 * takeoverTimeout)
+	if err := os.Chtimes(guard, abandoned, abandoned); err != nil {
+		t.Fatal(err)
+	}
+	if holder, err := local.Acquire(second); err != nil || holder != nil {
+		t.Fatalf("expected the abandoned takeover to be cleared, got %v (%v)", holder, err)
+	}
+	if _, err := os.Stat(guard); !os.IsNotExist(err) {
+		t.Errorf("expected the takeover guard to be removed, got %v", err)
+	}
+}
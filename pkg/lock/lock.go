@@ -0,0 +1,129 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const (
+	// how long a lease survives without being renewed, eg if the cli is killed mid deploy
+	leaseDuration = 5 * time.Minute
+	renewInterval = time.Minute
+
+	// how many times a backend retries when the lease changes under it while acquiring
+	maxAcquireAttempts = 10
+	acquireRetryDelay  = 100 * time.Millisecond
+)
+
+// Lease records who is currently deploying to a workspace
+type Lease struct {
+	ID      string    `json:"id"`
+	Holder  string    `json:"holder"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+	Expires time.Time `json:"expires"`
+}
+
+func (l *Lease) Expired() bool {
+	return time.Now().After(l.Expires)
+}
+
+func (l *Lease) renew() {
+	l.Expires = time.Now().Add(leaseDuration)
+}
+
+// Backend stores the workspace lease somewhere every deployer can see it
+type Backend interface {
+	Name() string
+	// Acquire takes the lease if it's free or expired, otherwise it returns the current holder
+	Acquire(lease *Lease) (*Lease, error)
+	Renew(lease *Lease) error
+	Release(lease *Lease) error
+	Get() (*Lease, error)
+	Break() error
+}
+
+type HeldError struct {
+	Lease *Lease
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf(
+		"workspace is locked by %s on %s, running `plural %s` since %s.  If you're sure it's stale, run `plural lock break`",
+		e.Lease.Holder, e.Lease.Host, e.Lease.Command, e.Lease.Started.Local().Format(time.RFC1123),
+	)
+}
+
+// Lock is a held lease that's renewed in the background until released
+type Lock struct {
+	backend Backend
+	lease   *Lease
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func NewLease(command string) *Lease {
+	conf := config.Read()
+	host, _ := os.Hostname()
+	lease := &Lease{
+		ID:      crypto.RandString(16),
+		Holder:  conf.Email,
+		Host:    host,
+		Command: command,
+		Started: time.Now(),
+	}
+	lease.renew()
+	return lease
+}
+
+func Acquire(backend Backend, command string) (*Lock, error) {
+	lease := NewLease(command)
+	holder, err := backend.Acquire(lease)
+	if err != nil {
+		return nil, err
+	}
+
+	if holder != nil {
+		return nil, &HeldError{Lease: holder}
+	}
+
+	l := &Lock{backend: backend, lease: lease, stop: make(chan struct{})}
+	go l.heartbeat()
+	return l, nil
+}
+
+func (l *Lock) heartbeat() {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.lease.renew()
+			if err := l.backend.Renew(l.lease); err != nil {
+				utils.Warn("failed to renew workspace lock: %s\n", err)
+			}
+		}
+	}
+}
+
+// Release gives up the lease, it's safe to call more than once
+func (l *Lock) Release() (err error) {
+	if l == nil {
+		return
+	}
+
+	l.once.Do(func() {
+		close(l.stop)
+		err = l.backend.Release(l.lease)
+	})
+	return
+}
@@ -6,6 +6,9 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/environment"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
 )
 
 
@@ -36,6 +39,12 @@ type VersionedContext struct {
 }
 
 func ContextPath() string {
+	if environment.Current() != "" {
+		if root, err := git.Root(); err == nil {
+			return filepath.Join(root, "context.yaml")
+		}
+	}
+
 	path, _ := filepath.Abs("context.yaml")
 	return path
 }
@@ -52,7 +61,20 @@ func BuildContext(path string, insts []*api.Installation) error {
 	return ctx.Write(path)
 }
 
+// ReadContext reads the context.yaml at path.  In an environment, its configuration is
+// overlaid key by key over the shared context.yaml at the root of the repo
 func ReadContext(path string) (c *Context, err error) {
+	base, ok := overlaid(path)
+	if !ok || (!utils.Exists(path) && !utils.Exists(base)) {
+		return readContext(path)
+	}
+
+	c = &Context{}
+	err = readOverlaid(base, path, c)
+	return
+}
+
+func readContext(path string) (c *Context, err error) {
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		return
@@ -86,7 +108,26 @@ func (c *Context) AddBundle(repo, name string) {
 	c.Bundles = append(c.Bundles, &Bundle{Repository: repo, Name: name})
 }
 
+// Write saves the context to path.  In an environment, only what differs from the shared
+// context.yaml is written, so later changes to the shared configuration still apply
 func (c *Context) Write(path string) error {
+	base, _ := overlaid(path)
+	return c.writeOver(path, base)
+}
+
+// writeOver writes the context to path as an overlay of the shared context.yaml at base,
+// or in full if base is empty or doesn't exist
+func (c *Context) writeOver(path, base string) error {
+	if base != "" {
+		overlay, found, err := overlayOf(base, c, &Context{})
+		if err != nil {
+			return err
+		}
+		if found {
+			return writeOverlay(path, "Context", nil, overlay)
+		}
+	}
+
 	versioned := &VersionedContext{
 		ApiVersion: "plural.sh/v1alpha1",
 		Kind: "Context",
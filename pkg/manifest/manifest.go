@@ -7,12 +7,14 @@ import (
 	"path/filepath"
 
 	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/environment"
 	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
 	"gopkg.in/yaml.v2"
 )
 
 func ProjectManifestPath() string {
-	root, found := utils.ProjectRoot()
+	root, found := workspaceRoot()
 	if !found {
 		path, _ := filepath.Abs("workspace.yaml")
 		return path
@@ -21,8 +23,34 @@ func ProjectManifestPath() string {
 	return filepath.Join(root, "workspace.yaml")
 }
 
+// workspaceRoot is the active environment's directory if there is one, otherwise
+// wherever the shared workspace.yaml lives
+func workspaceRoot() (string, bool) {
+	if environment.Current() != "" {
+		root, err := git.Root()
+		return root, err == nil
+	}
+
+	return utils.ProjectRoot()
+}
+
+// overlaid returns the shared file that the file at path overlays, if path belongs to
+// the active environment
+func overlaid(path string) (string, bool) {
+	if environment.Current() == "" {
+		return "", false
+	}
+
+	root, err := git.Root()
+	top, topErr := git.TopLevel()
+	if err != nil || topErr != nil || filepath.Dir(path) != root {
+		return "", false
+	}
+	return filepath.Join(top, filepath.Base(path)), true
+}
+
 func ManifestPath(repo string) (string, error) {
-	root, found := utils.ProjectRoot()
+	root, found := workspaceRoot()
 	if !found {
 		return "", fmt.Errorf("You're not within an installation repo")
 	}
@@ -30,7 +58,19 @@ func ManifestPath(repo string) (string, error) {
 	return filepath.Join(root, repo, "manifest.yaml"), nil
 }
 
+// Write saves the manifest to path.  In an environment, only what differs from the shared
+// workspace.yaml is written, so later changes to the shared settings still apply
 func (m *ProjectManifest) Write(path string) error {
+	if base, ok := overlaid(path); ok {
+		overlay, found, err := overlayOf(base, m, &ProjectManifest{})
+		if err != nil {
+			return err
+		}
+		if found {
+			return writeOverlay(path, "ProjectManifest", &Metadata{Name: m.Cluster}, overlay)
+		}
+	}
+
 	versioned := &VersionedProjectManifest{
 		ApiVersion: "plural.sh/v1alpha1",
 		Kind:       "ProjectManifest",
@@ -51,7 +91,24 @@ func FetchProject() (*ProjectManifest, error) {
 	return ReadProject(path)
 }
 
+// ReadProject reads the workspace.yaml at path.  In an environment, its settings are
+// overlaid over those of the shared workspace.yaml at the root of the repo
 func ReadProject(path string) (man *ProjectManifest, err error) {
+	base, ok := overlaid(path)
+	if !ok {
+		return readProject(path)
+	}
+
+	if !utils.Exists(path) && !utils.Exists(base) {
+		return readProject(path)
+	}
+
+	man = &ProjectManifest{}
+	err = readOverlaid(base, path, man)
+	return
+}
+
+func readProject(path string) (man *ProjectManifest, err error) {
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		err = fmt.Errorf("could not find workspace.yaml file, you might need to run `plural init`")
@@ -70,6 +127,26 @@ func ReadProject(path string) (man *ProjectManifest, err error) {
 	return
 }
 
+// Chart finds the chart named name, if there is one
+func (m *Manifest) Chart(name string) *ChartManifest {
+	if m == nil {
+		return nil
+	}
+
+	for _, chart := range m.Charts {
+		if chart.Name == name {
+			return chart
+		}
+	}
+	return nil
+}
+
+// Pinned reports whether the chart named name is pinned to its version
+func (m *Manifest) Pinned(name string) bool {
+	chart := m.Chart(name)
+	return chart != nil && chart.Pinned
+}
+
 func (m *Manifest) Write(path string) error {
 	versioned := &VersionedManifest{
 		ApiVersion: "plural.sh/v1alpha1",
@@ -0,0 +1,159 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+type spec = map[interface{}]interface{}
+
+// versionedOverlay is how an environment's workspace.yaml or context.yaml is written,
+// with only the settings that differ from the shared file in its spec
+type versionedOverlay struct {
+	ApiVersion string    `yaml:"apiVersion"`
+	Kind       string    `yaml:"kind"`
+	Metadata   *Metadata `yaml:"metadata,omitempty"`
+	Spec       spec      `yaml:"spec"`
+}
+
+// readOverlaid reads the file at path overlaid over the shared file at base into v.
+// Settings are merged key by key, and anything set in the overlay wins, even false or
+// empty values.  Either file may be missing.
+func readOverlaid(base, path string, v interface{}) error {
+	shared, err := readSpec(base)
+	if os.IsNotExist(err) {
+		shared, err = spec{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	overlay, err := readSpec(path)
+	if os.IsNotExist(err) {
+		overlay, err = spec{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	return fromSpec(mergeSpec(shared, overlay), v)
+}
+
+// overlayOf is what must be written to an environment's file so that, overlaid over
+// the shared file at base, it reads back as v.  ok is false if there is no shared file,
+// in which case all of v should be written
+func overlayOf(base string, v interface{}, empty interface{}) (spec, bool, error) {
+	shared, err := readSpec(base)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// round trip the shared file through the same type as v, so both are formatted the same
+	if err := fromSpec(shared, empty); err != nil {
+		return nil, false, err
+	}
+	if shared, err = toSpec(empty); err != nil {
+		return nil, false, err
+	}
+
+	current, err := toSpec(v)
+	if err != nil {
+		return nil, false, err
+	}
+	return diffSpec(shared, current), true, nil
+}
+
+func writeOverlay(path, kind string, meta *Metadata, overlay spec) error {
+	io, err := yaml.Marshal(&versionedOverlay{ApiVersion: "plural.sh/v1alpha1", Kind: kind, Metadata: meta, Spec: overlay})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, io, 0644)
+}
+
+// readSpec reads the spec of the versioned yaml file at path, or the whole file if it
+// isn't versioned
+func readSpec(path string) (spec, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := spec{}
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+	if s, ok := doc["spec"].(spec); ok {
+		return s, nil
+	}
+	return doc, nil
+}
+
+func toSpec(v interface{}) (spec, error) {
+	io, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := spec{}
+	err = yaml.Unmarshal(io, &s)
+	return s, err
+}
+
+func fromSpec(s spec, v interface{}) error {
+	io, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(io, v)
+}
+
+// mergeSpec deep merges overlay over base, lists and scalars in overlay replace those in base
+func mergeSpec(base, overlay spec) spec {
+	result := spec{}
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for k, v := range overlay {
+		sub, isMap := v.(spec)
+		prev, wasMap := result[k].(spec)
+		if isMap && wasMap {
+			result[k] = mergeSpec(prev, sub)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// diffSpec is the smallest overlay that merges over base to give s
+func diffSpec(base, s spec) spec {
+	result := spec{}
+	for k, v := range s {
+		prev, ok := base[k]
+		if !ok {
+			result[k] = v
+			continue
+		}
+
+		sub, isMap := v.(spec)
+		prevSub, wasMap := prev.(spec)
+		if isMap && wasMap {
+			if diff := diffSpec(prevSub, sub); len(diff) > 0 {
+				result[k] = diff
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(prev, v) {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,205 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/environment"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeSpec(t *testing.T) {
+	base := spec{
+		"cluster": "shared",
+		"bucket":  "bucket",
+		"network": spec{"subdomain": "plural.sh", "pluraldns": true},
+		"tags":    []interface{}{"a", "b"},
+	}
+	overlay := spec{
+		"cluster": "dev",
+		"network": spec{"pluraldns": false},
+		"tags":    []interface{}{"c"},
+	}
+
+	expected := spec{
+		"cluster": "dev",
+		"bucket":  "bucket",
+		"network": spec{"subdomain": "plural.sh", "pluraldns": false},
+		"tags":    []interface{}{"c"},
+	}
+	if merged := mergeSpec(base, overlay); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("mergeSpec() = %v, expected %v", merged, expected)
+	}
+
+	if diff := diffSpec(base, expected); !reflect.DeepEqual(diff, overlay) {
+		t.Errorf("diffSpec() = %v, expected %v", diff, overlay)
+	}
+}
+
+func withEnvironment(t *testing.T, env string, files map[string]string) string {
+	root, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ = filepath.EvalSymlinks(root)
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	envDir := filepath.Join(root, environment.Dir, env)
+	if err := os.Chdir(envDir); err != nil {
+		t.Fatal(err)
+	}
+	environment.Select(env)
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		environment.Select("")
+	})
+	return envDir
+}
+
+func TestProjectOverlay(t *testing.T) {
+	envDir := withEnvironment(t, "dev", map[string]string{
+		"workspace.yaml": `apiVersion: plural.sh/v1alpha1
+kind: ProjectManifest
+spec:
+  cluster: shared
+  bucket: shared-bucket
+  provider: aws
+  network:
+    subdomain: shared.plural.sh
+    pluraldns: true
+`,
+		"environments/dev/workspace.yaml": `apiVersion: plural.sh/v1alpha1
+kind: ProjectManifest
+spec:
+  cluster: dev
+  network:
+    pluraldns: false
+`,
+	})
+
+	path := ProjectManifestPath()
+	if path != filepath.Join(envDir, "workspace.yaml") {
+		t.Fatalf("expected the environment's workspace.yaml, got %s", path)
+	}
+
+	man, err := ReadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Cluster != "dev" || man.Bucket != "shared-bucket" || man.Network.Subdomain != "shared.plural.sh" || man.Network.PluralDns {
+		t.Fatalf("unexpected overlaid manifest %+v %+v", man, man.Network)
+	}
+
+	// writing back what was read shouldn't copy the shared settings into the overlay
+	man.Region = "us-east-2"
+	if err := man.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := readSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := spec{"cluster": "dev", "region": "us-east-2", "network": spec{"pluraldns": false}}
+	if !reflect.DeepEqual(written, expected) {
+		t.Errorf("wrote overlay %v, expected %v", written, expected)
+	}
+
+	// so later changes to the shared workspace.yaml still apply
+	shared := filepath.Join(filepath.Dir(filepath.Dir(envDir)), "workspace.yaml")
+	if err := (&ProjectManifest{Cluster: "shared", Bucket: "new-bucket", Provider: "aws"}).Write(shared); err != nil {
+		t.Fatal(err)
+	}
+	if man, err = ReadProject(path); err != nil || man.Bucket != "new-bucket" || man.Cluster != "dev" {
+		t.Errorf("expected the shared bucket to change, got %+v (%v)", man, err)
+	}
+}
+
+func TestContextOverlay(t *testing.T) {
+	envDir := withEnvironment(t, "dev", map[string]string{
+		"context.yaml": `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  bundles:
+  - repository: airflow
+    name: airflow-aws
+  configuration:
+    airflow:
+      hostname: airflow.shared.plural.sh
+      replicas: 3
+      sso: true
+`,
+		"environments/dev/context.yaml": `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  configuration:
+    airflow:
+      hostname: airflow.dev.plural.sh
+      sso: false
+`,
+	})
+
+	path := ContextPath()
+	ctx, err := ReadContext(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{"hostname": "airflow.dev.plural.sh", "replicas": 3, "sso": false}
+	if !reflect.DeepEqual(ctx.Configuration["airflow"], expected) {
+		t.Fatalf("overlaid configuration %v, expected %v", ctx.Configuration["airflow"], expected)
+	}
+	if len(ctx.Bundles) != 1 {
+		t.Errorf("expected the shared bundles, got %v", ctx.Bundles)
+	}
+
+	ctx.Configuration["grafana"] = map[string]interface{}{"hostname": "grafana.dev.plural.sh"}
+	ctx.AddBundle("grafana", "grafana-aws")
+	if err := ctx.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(envDir, "context.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written struct {
+		Spec struct {
+			Bundles       []*Bundle
+			Configuration map[string]map[string]interface{}
+		}
+	}
+	if err := yaml.Unmarshal(contents, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedConf := map[string]map[string]interface{}{
+		"airflow": {"hostname": "airflow.dev.plural.sh", "sso": false},
+		"grafana": {"hostname": "grafana.dev.plural.sh"},
+	}
+	if !reflect.DeepEqual(written.Spec.Configuration, expectedConf) {
+		t.Errorf("wrote configuration %v, expected %v", written.Spec.Configuration, expectedConf)
+	}
+	if len(written.Spec.Bundles) != 2 {
+		t.Errorf("expected the added bundle to be written with the shared one, got %v", written.Spec.Bundles)
+	}
+}
@@ -0,0 +1,276 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/environment"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+// configuration keys that almost always differ between environments, so are never promoted
+var envSpecific = regexp.MustCompile(`(?i)(domain|host|bucket|url|uri|endpoint|cluster|project|region|dns|zone|account|arn|email|cidr)`)
+
+// Promotion is what it takes to bring a repo in one workspace up to date with another
+type Promotion struct {
+	Repo   string
+	Charts []*VersionChange
+	Config []*ConfigChange
+	// manifest.yaml doesn't record terraform versions, so the repo's terraform modules
+	// are listed but can't be compared
+	Terraform []string
+}
+
+type VersionChange struct {
+	Chart     string
+	Current   string
+	Promoted  string
+	VersionId string
+}
+
+type ConfigChange struct {
+	Key      string
+	Current  interface{}
+	Promoted interface{}
+	// environment specific keys are shown but left alone
+	Skipped bool
+}
+
+// Empty reports whether there is anything to apply
+func (p *Promotion) Empty() bool {
+	if len(p.Charts) > 0 {
+		return false
+	}
+
+	for _, c := range p.Config {
+		if !c.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// PlanPromotion compares chart versions and configuration of each repo between the
+// workspaces at from and to, defaulting to every repo they both have
+func PlanPromotion(from, to string, repos []string) ([]*Promotion, error) {
+	if len(repos) == 0 {
+		var err error
+		if repos, err = sharedRepos(from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	// compare what each workspace actually ends up with, environments inherit most
+	// of their configuration from the shared context.yaml
+	fromCtx, err := effectiveContext(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toCtx, err := effectiveContext(to)
+	if err != nil {
+		return nil, err
+	}
+
+	markers := envMarkers(from)
+	promotions := make([]*Promotion, 0, len(repos))
+	for _, repo := range repos {
+		fromMan, err := Read(filepath.Join(from, repo, "manifest.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not installed in %s", repo, from)
+		}
+
+		toMan, err := Read(filepath.Join(to, repo, "manifest.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not installed in %s", repo, to)
+		}
+
+		promotion := &Promotion{Repo: repo, Charts: diffCharts(fromMan, toMan), Terraform: []string{}}
+		promotion.Config = diffConfig(fromCtx.Configuration[repo], toCtx.Configuration[repo], markers)
+		for _, tf := range fromMan.Terraform {
+			promotion.Terraform = append(promotion.Terraform, tf.Name)
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, nil
+}
+
+// ApplyPromotions writes the promoted chart versions and configuration into the workspace at to.
+// Promoted charts are pinned, so the next build uses them rather than what's installed in the api.
+// If to is an environment, only what then differs from the shared context.yaml is written to its own.
+func ApplyPromotions(to string, promotions []*Promotion) error {
+	ctx, err := effectiveContext(to)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range promotions {
+		if len(p.Charts) > 0 {
+			path := filepath.Join(to, p.Repo, "manifest.yaml")
+			man, err := Read(path)
+			if err != nil {
+				return err
+			}
+
+			for _, change := range p.Charts {
+				for _, chart := range man.Charts {
+					if chart.Name == change.Chart {
+						chart.Version = change.Promoted
+						chart.VersionId = change.VersionId
+						chart.Pinned = true
+					}
+				}
+			}
+
+			if err := man.Write(path); err != nil {
+				return err
+			}
+		}
+
+		for _, change := range p.Config {
+			if change.Skipped {
+				continue
+			}
+
+			if _, ok := ctx.Configuration[p.Repo]; !ok {
+				ctx.Configuration[p.Repo] = map[string]interface{}{}
+			}
+			ctx.Configuration[p.Repo][change.Key] = change.Promoted
+		}
+	}
+
+	return ctx.writeOver(filepath.Join(to, "context.yaml"), sharedFile(to, "context.yaml"))
+}
+
+func diffCharts(from, to *Manifest) []*VersionChange {
+	changes := []*VersionChange{}
+	for _, fc := range from.Charts {
+		for _, tc := range to.Charts {
+			if fc.Name == tc.Name && fc.Version != tc.Version {
+				changes = append(changes, &VersionChange{Chart: fc.Name, Current: tc.Version, Promoted: fc.Version, VersionId: fc.VersionId})
+			}
+		}
+	}
+	return changes
+}
+
+func diffConfig(from, to map[string]interface{}, markers []string) []*ConfigChange {
+	keys := make([]string, 0, len(from))
+	for k := range from {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changes := []*ConfigChange{}
+	for _, k := range keys {
+		current, promoted := to[k], from[k]
+		if reflect.DeepEqual(current, promoted) {
+			continue
+		}
+
+		changes = append(changes, &ConfigChange{
+			Key:      k,
+			Current:  current,
+			Promoted: promoted,
+			Skipped:  isEnvSpecific(k, promoted, markers),
+		})
+	}
+	return changes
+}
+
+func isEnvSpecific(key string, val interface{}, markers []string) bool {
+	if envSpecific.MatchString(key) {
+		return true
+	}
+
+	str := fmt.Sprintf("%v", val)
+	for _, marker := range markers {
+		if strings.Contains(str, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// envMarkers are values that only make sense in the workspace at path, like its
+// cluster name, so any configuration mentioning them is environment specific
+func envMarkers(path string) []string {
+	project := &ProjectManifest{}
+	if err := readEffective(path, "workspace.yaml", project); err != nil {
+		return []string{}
+	}
+
+	markers := []string{}
+	for _, m := range []string{project.Cluster, project.Bucket, project.Project, project.BucketPrefix} {
+		if m != "" {
+			markers = append(markers, m)
+		}
+	}
+	if project.Network != nil && project.Network.Subdomain != "" {
+		markers = append(markers, project.Network.Subdomain)
+	}
+	return markers
+}
+
+func sharedRepos(from, to string) ([]string, error) {
+	files, err := ioutil.ReadDir(from)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []string{}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		if utils.Exists(filepath.Join(from, f.Name(), "manifest.yaml")) && utils.Exists(filepath.Join(to, f.Name(), "manifest.yaml")) {
+			repos = append(repos, f.Name())
+		}
+	}
+	return repos, nil
+}
+
+// sharedFile is the file at the root of the repo that name overlays, if the workspace is
+// an environment, or else ""
+func sharedFile(workspace, name string) string {
+	envs := filepath.Dir(workspace)
+	if filepath.Base(envs) != environment.Dir {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(envs), name)
+}
+
+// readEffective reads the workspace's file called name into v, overlaid over the shared
+// one if the workspace is an environment
+func readEffective(workspace, name string, v interface{}) error {
+	path := filepath.Join(workspace, name)
+	if base := sharedFile(workspace, name); base != "" {
+		return readOverlaid(base, path, v)
+	}
+
+	s, err := readSpec(path)
+	if err != nil {
+		return err
+	}
+	return fromSpec(s, v)
+}
+
+func effectiveContext(workspace string) (*Context, error) {
+	ctx := NewContext()
+	err := readEffective(workspace, "context.yaml", ctx)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if ctx.Configuration == nil {
+		ctx.Configuration = map[string]map[string]interface{}{}
+	}
+	return ctx, nil
+}
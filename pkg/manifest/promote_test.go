@@ -0,0 +1,128 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func airflowManifest(version string) string {
+	return `apiVersion: plural.sh/v1alpha1
+kind: Manifest
+spec:
+  name: airflow
+  charts:
+  - name: airflow
+    versionid: airflow-` + version + `
+    version: ` + version + `
+  terraform:
+  - name: aws
+`
+}
+
+func TestPromoteEnvironments(t *testing.T) {
+	root, err := ioutil.TempDir("", "promote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"context.yaml": `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  configuration:
+    airflow:
+      hostname: airflow.shared.plural.sh
+      replicas: 3
+`,
+		"environments/staging/workspace.yaml": `apiVersion: plural.sh/v1alpha1
+kind: ProjectManifest
+spec:
+  cluster: staging
+  network:
+    subdomain: staging.plural.sh
+`,
+		"environments/staging/context.yaml": `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  configuration:
+    airflow:
+      hostname: airflow.staging.plural.sh
+      version: "2"
+`,
+		"environments/prod/context.yaml": `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  configuration:
+    airflow:
+      hostname: airflow.prod.plural.sh
+      replicas: 1
+`,
+		"environments/staging/airflow/manifest.yaml": airflowManifest("0.2.0"),
+		"environments/prod/airflow/manifest.yaml":    airflowManifest("0.1.0"),
+	}
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from, to := filepath.Join(root, "environments", "staging"), filepath.Join(root, "environments", "prod")
+	promotions, err := PlanPromotion(from, to, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promotions) != 1 {
+		t.Fatalf("expected only airflow to be promoted, got %v", promotions)
+	}
+
+	// replicas is inherited in staging but overridden in prod, so it's still promoted
+	p := promotions[0]
+	expected := []*ConfigChange{
+		{Key: "hostname", Current: "airflow.prod.plural.sh", Promoted: "airflow.staging.plural.sh", Skipped: true},
+		{Key: "replicas", Current: 1, Promoted: 3},
+		{Key: "version", Promoted: "2"},
+	}
+	if !reflect.DeepEqual(p.Config, expected) {
+		t.Errorf("planned config changes %v, expected %v", p.Config, expected)
+	}
+	if len(p.Charts) != 1 || p.Charts[0].Promoted != "0.2.0" {
+		t.Errorf("planned chart changes %v", p.Charts)
+	}
+	if !reflect.DeepEqual(p.Terraform, []string{"aws"}) {
+		t.Errorf("expected the terraform modules to be listed, got %v", p.Terraform)
+	}
+
+	if err := ApplyPromotions(to, promotions); err != nil {
+		t.Fatal(err)
+	}
+
+	// prod now matches the shared replicas, so its overlay no longer sets them
+	written, err := readSpec(filepath.Join(to, "context.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSpec := spec{"configuration": spec{"airflow": spec{"hostname": "airflow.prod.plural.sh", "version": "2"}}}
+	if !reflect.DeepEqual(written, expectedSpec) {
+		t.Errorf("wrote overlay %v, expected %v", written, expectedSpec)
+	}
+
+	man, err := Read(filepath.Join(to, "airflow", "manifest.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart := man.Charts[0]; chart.Version != "0.2.0" || chart.VersionId != "airflow-0.2.0" || !chart.Pinned {
+		t.Errorf("expected the promoted chart to be pinned, got %+v", chart)
+	}
+
+	if promotions, err = PlanPromotion(from, to, nil); err != nil || !promotions[0].Empty() {
+		t.Errorf("expected nothing left to promote, got %v (%v)", promotions[0].Config, err)
+	}
+}
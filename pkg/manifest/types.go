@@ -5,6 +5,9 @@ type ChartManifest struct {
 	Name      string
 	VersionId string
 	Version   string
+	// pinned charts are built at VersionId instead of whatever is installed in the api,
+	// eg after being promoted from another environment
+	Pinned bool `yaml:"pinned,omitempty"`
 }
 
 type TerraformManifest struct {
@@ -14,6 +17,8 @@ type TerraformManifest struct {
 
 type Dependency struct {
 	Repo string
+	// helm or terraform, depending on what needs the dependency
+	Type string `yaml:"type,omitempty"`
 }
 
 type Metadata struct {
@@ -56,9 +61,21 @@ type ProjectManifest struct {
 	Owner        *Owner
 	Network      *NetworkConfig
 	BucketPrefix string `yaml:"bucketPrefix"`
+	Lock         string `yaml:"lock,omitempty"`
+	Webhooks     []*Webhook `yaml:"webhooks,omitempty"`
+	// semver constraints for terraform, helm and kubectl, eg {terraform: "~1.0"}
+	Tools        map[string]string `yaml:"tools,omitempty"`
 	Context      map[string]interface{}
 }
 
+type Webhook struct {
+	Url    string `yaml:"url"`
+	Format string `yaml:"format,omitempty"`
+	// if set, only notify on these results, eg [failed]
+	On       []string `yaml:"on,omitempty"`
+	Template string   `yaml:"template,omitempty"`
+}
+
 type VersionedManifest struct {
 	ApiVersion string `yaml:"apiVersion"`
 	Kind       string
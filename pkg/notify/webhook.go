@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/manifest"
+)
+
+const (
+	FormatJson  = "json"
+	FormatSlack = "slack"
+
+	attempts = 3
+)
+
+const slackTemplate = `{{ $icon := ":white_check_mark:" }}{{ if eq .Result "failed" }}{{ $icon = ":x:" }}{{ end -}}
+{"text": {{ printf "%s *plural %s* on *%s* %s by %s in %.0fs (commit %s)" $icon .Command .Cluster .Result .User .Duration (short .Commit) | json }},
+ "blocks": [
+  {"type": "section", "text": {"type": "mrkdwn", "text": {{ printf "%s *plural %s* on *%s* %s by %s in %.0fs (commit %s)" $icon .Command .Cluster .Result .User .Duration (short .Commit) | json }}}}
+  {{- range .Repos }},
+  {"type": "context", "elements": [{"type": "mrkdwn", "text": {{ printf "*%s* %s (%.0fs)%s" .Repo .Status .Duration (errorSuffix .Error) | json }}}]}
+  {{- end }}
+ ]
+}`
+
+// Payload is what gets sent to webhooks when a deploy finishes
+type Payload struct {
+	Cluster  string        `json:"cluster"`
+	Command  string        `json:"command"`
+	User     string        `json:"user"`
+	Commit   string        `json:"commit"`
+	Result   string        `json:"result"`
+	Error    string        `json:"error,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration float64       `json:"duration"`
+	Repos    []*RepoStatus `json:"repos"`
+}
+
+type RepoStatus struct {
+	Repo     string  `json:"repo"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func FromReport(report *executor.Report, cluster, user, commit string, err error) *Payload {
+	payload := &Payload{
+		Cluster:  cluster,
+		Command:  report.Command,
+		User:     user,
+		Commit:   commit,
+		Result:   executor.StatusSucceeded,
+		Started:  report.Started,
+		Duration: time.Since(report.Started).Seconds(),
+		Repos:    make([]*RepoStatus, 0, len(report.Repos)),
+	}
+
+	if err != nil {
+		payload.Result = executor.StatusFailed
+		payload.Error = err.Error()
+	}
+
+	for _, repo := range report.Repos {
+		payload.Repos = append(payload.Repos, &RepoStatus{Repo: repo.Repo, Status: repo.Status, Duration: repo.Duration, Error: repo.Error})
+	}
+	return payload
+}
+
+// Send posts the payload to every webhook interested in its result, returning the first error
+func Send(hooks []*manifest.Webhook, payload *Payload) (err error) {
+	for _, hook := range hooks {
+		if !wants(hook, payload.Result) {
+			continue
+		}
+
+		if sendErr := send(hook, payload); sendErr != nil && err == nil {
+			err = sendErr
+		}
+	}
+	return
+}
+
+func wants(hook *manifest.Webhook, result string) bool {
+	if len(hook.On) == 0 {
+		return true
+	}
+
+	for _, on := range hook.On {
+		if on == result {
+			return true
+		}
+	}
+	return false
+}
+
+func send(hook *manifest.Webhook, payload *Payload) error {
+	body, err := render(hook, payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for attempt := 1; ; attempt++ {
+		err = post(client, hook.Url, body)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
+
+func post(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %s", url, resp.Status)
+	}
+	return nil
+}
+
+func render(hook *manifest.Webhook, payload *Payload) ([]byte, error) {
+	tpl := hook.Template
+	if tpl == "" {
+		switch hook.Format {
+		case "", FormatJson:
+			return json.Marshal(payload)
+		case FormatSlack:
+			tpl = slackTemplate
+		default:
+			return nil, fmt.Errorf("unknown webhook format %s, must be one of %s or %s", hook.Format, FormatJson, FormatSlack)
+		}
+	}
+
+	t, err := template.New("webhook").Funcs(funcs).Parse(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, payload)
+	return buf.Bytes(), err
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		res, err := json.Marshal(v)
+		return string(res), err
+	},
+	"short": func(commit string) string {
+		if len(commit) > 8 {
+			return commit[:8]
+		}
+		return commit
+	},
+	"errorSuffix": func(err string) string {
+		if err == "" {
+			return ""
+		}
+		return ": " + strings.SplitN(err, "\n", 2)[0]
+	},
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/manifest"
+)
+
+func TestSend(t *testing.T) {
+	var mut sync.Mutex
+	received := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mut.Lock()
+		defer mut.Unlock()
+		received[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	report := executor.NewReport("deploy")
+	repo := executor.NewRepoReport("airflow")
+	repo.Finish(fmt.Errorf("helm upgrade failed\nError: timed out"))
+	report.Add(repo)
+	payload := FromReport(report, "prod", "someone@plural.sh", "0123456789abcdef", fmt.Errorf("deploy failed"))
+
+	hooks := []*manifest.Webhook{
+		{Url: server.URL + "/json"},
+		{Url: server.URL + "/slack", Format: FormatSlack, On: []string{executor.StatusFailed}},
+		{Url: server.URL + "/succeeded", On: []string{executor.StatusSucceeded}},
+		{Url: server.URL + "/custom", Template: `{{ .Cluster }} {{ .Result }} {{ short .Commit }}`},
+	}
+	if err := Send(hooks, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := received["/succeeded"]; ok {
+		t.Errorf("expected the hook only interested in successes not to be sent a failure")
+	}
+
+	sent := &Payload{}
+	if err := json.Unmarshal([]byte(received["/json"]), sent); err != nil {
+		t.Fatalf("json hook was sent %q: %s", received["/json"], err)
+	}
+	if sent.Result != executor.StatusFailed || sent.Error != "deploy failed" || len(sent.Repos) != 1 || sent.Repos[0].Status != executor.StatusFailed {
+		t.Errorf("unexpected json payload %s", received["/json"])
+	}
+
+	slack := struct{ Text string }{}
+	if err := json.Unmarshal([]byte(received["/slack"]), &slack); err != nil {
+		t.Fatalf("slack hook was sent invalid json %q: %s", received["/slack"], err)
+	}
+	if !strings.HasPrefix(slack.Text, ":x: *plural deploy* on *prod* failed") || !strings.Contains(slack.Text, "(commit 01234567)") {
+		t.Errorf("unexpected slack message %q", slack.Text)
+	}
+	if !strings.Contains(received["/slack"], "*airflow* failed (0s): helm upgrade failed\"") {
+		t.Errorf("expected only the first line of the repo's error in %s", received["/slack"])
+	}
+
+	if custom := received["/custom"]; custom != "prod failed 01234567" {
+		t.Errorf("custom template rendered %q", custom)
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := render(&manifest.Webhook{Format: "teams"}, &Payload{}); err == nil {
+		t.Errorf("expected an unknown format to fail")
+	}
+}
@@ -23,12 +23,12 @@ func (a *Crd) Key() string {
 }
 
 func (c *Crd) Push(repo string, sha string) (string, error) {
-	crdSha, err := executor.MkHash(c.File, []string{})
+	crdSha, err := executor.MkHash(c.File, nil)
 	if err != nil {
 		return sha, err
 	}
 
-	chartSha, err := executor.MkHash(c.Chart, []string{})
+	chartSha, err := executor.MkHash(c.Chart, nil)
 	if err != nil {
 		return sha, err
 	}
@@ -18,7 +18,7 @@ func (a *ResourceDefinition) Key() string {
 }
 
 func (a *ResourceDefinition) Push(repo string, sha string) (string, error) {
-	newsha, err := executor.MkHash(a.File, []string{})
+	newsha, err := executor.MkHash(a.File, nil)
 	if err != nil || newsha == sha {
 		utils.Highlight("No change for %s\n", a.File)
 		return sha, err
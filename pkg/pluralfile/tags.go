@@ -36,7 +36,7 @@ func (a *Tags) Key() string {
 }
 
 func (t *Tags) Push(repo string, sha string) (string, error) {
-	newsha, err := executor.MkHash(t.File, []string{})
+	newsha, err := executor.MkHash(t.File, nil)
 	if err != nil || newsha == sha {
 		if err == nil {
 			utils.Highlight("No change for %s\n", t.File)
@@ -21,7 +21,7 @@ func (a *Terraform) Key() string {
 }
 
 func (a *Terraform) Push(repo string, sha string) (string, error) {
-	newsha, err := executor.MkHash(a.File, []string{})
+	newsha, err := executor.MkHash(a.File, nil)
 	if err != nil || newsha == sha {
 		if err == nil {
 			utils.Highlight("No change for %s\n", a.File)
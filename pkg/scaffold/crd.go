@@ -3,7 +3,6 @@ package scaffold
 import (
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"path/filepath"
 
 	"github.com/pluralsh/plural/pkg/api"
@@ -16,7 +15,7 @@ func (s *Scaffold) buildCrds(wk *wkspace.Workspace) error {
 	for _, chartInst := range wk.Charts {
 		for _, crd := range chartInst.Version.Crds {
 			utils.Highlight(".")
-			if err := writeCrd(s.Root, &crd); err != nil {
+			if err := writeCrd(s.Root, chartInst.Version, &crd); err != nil {
 				fmt.Print("\n")
 				return err
 			}
@@ -27,13 +26,14 @@ func (s *Scaffold) buildCrds(wk *wkspace.Workspace) error {
 	return nil
 }
 
-func writeCrd(path string, crd *api.Crd) error {
-	resp, err := http.Get(crd.Blob)
+func writeCrd(path string, v *api.Version, crd *api.Crd) error {
+	body, err := api.Download(crd.Blob, v.Id, filepath.Join("crds", crd.Name))
 	if err != nil {
 		return err
 	}
+	defer body.Close()
 
-	contents, err := ioutil.ReadAll(resp.Body)
+	contents, err := ioutil.ReadAll(body)
 	if err != nil {
 		return err
 	}
@@ -396,6 +396,46 @@ func (s *Scaffold) createChart(w *wkspace.Workspace, name string) error {
 	return nil
 }
 
+// chartArchives are the tarballs helm dependency update downloads into the charts directory
+func (s *Scaffold) chartArchives(w *wkspace.Workspace) []string {
+	archives := []string{}
+	for _, dep := range s.chartDependencies(w, w.Installation.Repository.Name) {
+		if !strings.HasPrefix(dep.Repository, "file://") {
+			archives = append(archives, fmt.Sprintf("%s-%s.tgz", dep.Name, dep.Version))
+		}
+	}
+	return archives
+}
+
+// vendorCharts caches the chart archives fetched by helm dependency update, which are
+// immutable for a given version
+func (s *Scaffold) vendorCharts(w *wkspace.Workspace) error {
+	if s.Type != HELM {
+		return nil
+	}
+
+	for _, archive := range s.chartArchives(w) {
+		if err := api.Vendor(filepath.Join(s.Root, ChartsDir, archive), "charts", archive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCharts puts back any chart archive missing from the charts directory from the cache
+func (s *Scaffold) restoreCharts(w *wkspace.Workspace) error {
+	if s.Type != HELM {
+		return nil
+	}
+
+	for _, archive := range s.chartArchives(w) {
+		if err := api.Restore(filepath.Join(s.Root, ChartsDir, archive), "charts", archive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func repoUrl(w *wkspace.Workspace, repo string, chart string) string {
 	if w.Links != nil {
 		if path, ok := w.Links.Helm[chart]; ok {
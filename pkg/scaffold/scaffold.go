@@ -7,6 +7,7 @@ import (
 
 	"github.com/rodaine/hclencoder"
 
+	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
@@ -129,20 +130,25 @@ func (s *Scaffold) Execute(wk *wkspace.Workspace, force bool) error {
 		return err
 	}
 
-	ignore := []string{}
+	if api.Offline() {
+		// preflights like helm dependency update need the network, so restore what
+		// they fetched during the last online build instead
+		return s.restoreCharts(wk)
+	}
+
 	for _, preflight := range s.Preflight {
 		if force {
 			preflight.Sha = ""
 		}
 
-		sha, err := preflight.Execute(s.Root, ignore)
+		sha, err := preflight.Execute(s.Root, nil)
 		if err != nil {
 			return err
 		}
 		preflight.Sha = sha
 	}
 
-	return nil
+	return s.vendorCharts(wk)
 }
 
 func (s *Scaffold) executeType(wk *wkspace.Workspace) error {
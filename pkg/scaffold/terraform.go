@@ -3,7 +3,6 @@ package scaffold
 import (
 	"bytes"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -200,12 +199,13 @@ func (scaffold *Scaffold) buildOutputs(wk *wkspace.Workspace) error {
 }
 
 func untar(v *api.Version, tf *api.Terraform, dir string) error {
-	resp, err := http.Get(v.Package)
+	body, err := api.Download(v.Package, v.Id, tf.Name+".tgz")
 	if err != nil {
 		return err
 	}
+	defer body.Close()
 
-	return utils.Untar(resp.Body, dir, tf.Name)
+	return utils.Untar(body, dir, tf.Name)
 }
 
 func manualSection(contents, name string) string {
@@ -9,10 +9,16 @@ import (
 )
 
 func Cmd(conf *config.Config, program string, args ...string) error {
+	if err := CheckTool(program); err != nil {
+		return err
+	}
 	return MkCmd(conf, program, args...).Run()
 }
 
 func Exec(program string, args ...string) error {
+	if err := CheckTool(program); err != nil {
+		return err
+	}
 	cmd := exec.Command(program, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -58,6 +58,20 @@ func WriteFile(name string, content []byte) error {
 	return ioutil.WriteFile(name, content, 0644)
 }
 
+// MkIgnoredDir creates dir readable only by the current user, with a .gitignore so
+// nothing in it ever gets committed, even in repos whose own .gitignore predates it
+func MkIgnoredDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	ignore := filepath.Join(dir, ".gitignore")
+	if Exists(ignore) {
+		return nil
+	}
+	return ioutil.WriteFile(ignore, []byte("*\n"), 0644)
+}
+
 func WriteFileIfNotPresent(path, contents string) {
 	fullpath, _ := filepath.Abs(path)
 	if Exists(fullpath) {
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnsureAttributes adds any of lines missing from the .gitattributes at the top level of
+// the repo, so files plural has started writing are still encrypted in repos whose
+// .gitattributes predates them
+func EnsureAttributes(lines ...string) error {
+	return ensureLines(".gitattributes", lines)
+}
+
+// EnsureIgnored adds any of lines missing from the .gitignore at the top level of the repo,
+// for files plural writes that repos initialized before them would otherwise commit
+func EnsureIgnored(lines ...string) error {
+	return ensureLines(".gitignore", lines)
+}
+
+func ensureLines(file string, lines []string) error {
+	root, err := TopLevel()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(root, file)
+	missing, err := missingLines(path, lines)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	addition := strings.Join(missing, "\n") + "\n"
+	if len(contents) > 0 && !strings.HasSuffix(string(contents), "\n") {
+		addition = "\n" + addition
+	}
+	_, err = f.WriteString(addition)
+	return err
+}
+
+// MissingAttributes returns which of lines aren't in the .gitattributes at root
+func MissingAttributes(root string, lines []string) ([]string, error) {
+	return missingLines(filepath.Join(root, ".gitattributes"), lines)
+}
+
+func missingLines(path string, lines []string) ([]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(contents), "\n") {
+		present[normalizeLine(line)] = true
+	}
+
+	missing := []string{}
+	for _, line := range lines {
+		if norm := normalizeLine(line); norm != "" && !present[norm] {
+			missing = append(missing, line)
+		}
+	}
+	return missing, nil
+}
+
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnsureAttributes(t *testing.T) {
+	root, err := ioutil.TempDir("", "attributes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+
+	path := filepath.Join(root, ".gitattributes")
+	if err := ioutil.WriteFile(path, []byte("context.yaml filter=plural-crypt  diff=plural-crypt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{
+		"context.yaml filter=plural-crypt diff=plural-crypt",
+		".plural/history.jsonl filter=plural-crypt diff=plural-crypt",
+	}
+	missing, err := MissingAttributes(root, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(missing, lines[1:]) {
+		t.Errorf("MissingAttributes() = %v", missing)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := EnsureAttributes(lines...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contents, _ := ioutil.ReadFile(path)
+	expected := "context.yaml filter=plural-crypt  diff=plural-crypt\n.plural/history.jsonl filter=plural-crypt diff=plural-crypt\n"
+	if string(contents) != expected {
+		t.Errorf(".gitattributes is\n%s\nexpected\n%s", contents, expected)
+	}
+}
+
+func TestEnsureIgnored(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	if out, err := exec.Command("git", "init", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+
+	path := filepath.Join(root, ".gitignore")
+	if err := ioutil.WriteFile(path, []byte("/bin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := EnsureIgnored("/bin", "/.plural-lock*"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "/bin\n/.plural-lock*\n" {
+		t.Errorf(".gitignore is\n%s", contents)
+	}
+	if out, err := exec.Command("git", "-C", root, "check-ignore", ".plural-lock").CombinedOutput(); err != nil {
+		t.Errorf("expected the lock file to be ignored: %s", out)
+	}
+}
@@ -4,15 +4,27 @@ import (
 	"fmt"
 	"strings"
 	"bufio"
+	"github.com/pluralsh/plural/pkg/environment"
 	gogit "github.com/go-git/go-git/v5"
 )
 
+// Root returns the root of the workspace, which is the environment directory if an
+// environment is active, otherwise the top level of the git repo
 func Root() (string, error) {
+	root, err := TopLevel()
+	if err != nil {
+		return root, err
+	}
+	return environment.Path(root), nil
+}
+
+// TopLevel returns the top level of the git repo, regardless of environment
+func TopLevel() (string, error) {
 	return gitRaw("rev-parse", "--show-toplevel")
 }
 
 func Repo() (*gogit.Repository, error) {
-	root, err := Root()
+	root, err := TopLevel()
 	if err != nil {
 		return nil, err
 	}
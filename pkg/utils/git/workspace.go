@@ -1,11 +1,34 @@
 package git
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
+
+	"github.com/pluralsh/plural/pkg/environment"
 )
 
+// Modified lists modified files relative to the workspace root, so only files in the
+// active environment are included if there is one
 func Modified() ([]string, error) {
-	res, err := gitRaw("status", "--porcelain")
+	toplevel, err := TopLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	// -uall lists every untracked file, otherwise a new environment or repo is collapsed
+	// into its untracked parent directory
+	args := []string{"status", "--porcelain", "-uall"}
+	prefix := ""
+	if env := environment.Current(); env != "" {
+		prefix = path.Join(environment.Dir, env) + "/"
+		args = append(args, "--", prefix)
+	}
+
+	res, err := git(toplevel, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -13,9 +36,103 @@ func Modified() ([]string, error) {
 	result := make([]string, 0)
 	for _, line := range strings.Split(res, "\n") {
 		cols := strings.Fields(strings.TrimSpace(line))
-		if len(cols) > 1 {
-			result = append(result, cols[1])
+		if len(cols) > 1 && strings.HasPrefix(cols[1], prefix) {
+			result = append(result, strings.TrimPrefix(cols[1], prefix))
 		}
 	}
 	return result, nil
-}
\ No newline at end of file
+}
+
+func Head(root string) (string, error) {
+	return git(root, "rev-parse", "HEAD")
+}
+
+// HasChanges reports whether anything matching the pathspecs is modified or untracked
+func HasChanges(root string, pathspecs ...string) (bool, error) {
+	res, err := git(root, append([]string{"status", "--porcelain", "--"}, pathspecs...)...)
+	return res != "", err
+}
+
+// Restore restores paths to their contents at commit, leaving HEAD where it is.  Unlike
+// git checkout, files added to them since commit are removed
+func Restore(root, commit string, paths ...string) error {
+	args := append([]string{"restore", "--source", commit, "--staged", "--worktree", "--"}, paths...)
+	_, err := git(root, args...)
+	return err
+}
+
+// FirstChangeSince returns the earliest commit after base that touched path, or "" if there isn't one
+func FirstChangeSince(root, base, path string) (string, error) {
+	res, err := git(root, "log", "--reverse", "--format=%H", base+"..HEAD", "--", path)
+	if err != nil || res == "" {
+		return "", err
+	}
+	return strings.SplitN(res, "\n", 2)[0], nil
+}
+
+// Snapshot writes HEAD, with paths replaced by their working copy, as a tree without
+// touching the index, returning its hash.  Ignored files are left out, as in a commit
+func Snapshot(root string, paths ...string) (string, error) {
+	dir, err := ioutil.TempDir("", "plural-index")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	index := filepath.Join(dir, "index")
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+		res, err := execute(cmd)
+		return strings.TrimSpace(res), err
+	}
+
+	if _, err := run("read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := run(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return run("write-tree")
+}
+
+// SubTree returns the hash of path, relative to root, within treeish
+func SubTree(root, treeish, path string) (string, error) {
+	return git(root, "rev-parse", "--verify", treeish+":./"+filepath.ToSlash(path))
+}
+
+// HasObject reports whether the object is in the local repository, unpushed trees
+// written by Snapshot can be garbage collected or missing from other clones
+func HasObject(root, hash string) bool {
+	_, err := git(root, "cat-file", "-e", hash)
+	return err == nil
+}
+
+// CommitWithTrees returns the earliest commit after base where each path holds exactly
+// the tree it maps to, or "" if there isn't one
+func CommitWithTrees(root, base string, trees map[string]string) (string, error) {
+	paths := make([]string, 0, len(trees))
+	for p := range trees {
+		paths = append(paths, p)
+	}
+
+	res, err := git(root, append([]string{"log", "--reverse", "--format=%H", base + "..HEAD", "--"}, paths...)...)
+	if err != nil || res == "" {
+		return "", err
+	}
+
+	for _, commit := range strings.Split(res, "\n") {
+		matches := true
+		for p, tree := range trees {
+			if sub, err := SubTree(root, commit, p); err != nil || sub != tree {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return commit, nil
+		}
+	}
+	return "", nil
+}
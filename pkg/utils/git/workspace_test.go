@@ -0,0 +1,142 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/environment"
+)
+
+func TestModified(t *testing.T) {
+	root, err := ioutil.TempDir("", "modified")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@plural.sh"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	write := func(name string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init")
+	write("airflow/helm/values.yaml")
+	write("environments/prod/airflow/helm/values.yaml")
+	run("add", ".")
+	run("commit", "-m", "init")
+
+	write("airflow/terraform/main.tf")
+	write("environments/prod/airflow/terraform/main.tf")
+	write("environments/dev/airflow/helm/values.yaml")
+	write("environments/dev/airflow/terraform/main.tf")
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(filepath.Join(root, "airflow")); err != nil {
+		t.Fatal(err)
+	}
+	defer environment.Select("")
+
+	tests := []struct {
+		env      string
+		expected []string
+	}{
+		{env: "", expected: []string{
+			"airflow/terraform/main.tf",
+			"environments/dev/airflow/helm/values.yaml",
+			"environments/dev/airflow/terraform/main.tf",
+			"environments/prod/airflow/terraform/main.tf",
+		}},
+		{env: "prod", expected: []string{"airflow/terraform/main.tf"}},
+		{env: "dev", expected: []string{"airflow/helm/values.yaml", "airflow/terraform/main.tf"}},
+	}
+
+	for _, test := range tests {
+		environment.Select(test.env)
+		files, err := Modified()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(files)
+		if !reflect.DeepEqual(files, test.expected) {
+			t.Errorf("Modified() in environment %q = %v, expected %v", test.env, files, test.expected)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	root, err := ioutil.TempDir("", "restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@plural.sh"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, contents string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init")
+	write("airflow/helm/values.yaml", "v1")
+	write("airflow/terraform/main.tf", "v1")
+	run("add", ".")
+	run("commit", "-m", "v1")
+	commit := run("rev-parse", "HEAD")
+
+	write("airflow/helm/values.yaml", "v2")
+	write("airflow/helm/templates/job.yaml", "v2")
+	write("airflow/terraform/main.tf", "v2")
+	run("add", ".")
+	run("commit", "-m", "v2")
+
+	if err := Restore(root, commit, filepath.Join("airflow", "helm")); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents, _ := ioutil.ReadFile(filepath.Join(root, "airflow/helm/values.yaml")); string(contents) != "v1" {
+		t.Errorf("expected values.yaml to be restored, got %q", contents)
+	}
+	if _, err := os.Stat(filepath.Join(root, "airflow/helm/templates/job.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected the file added since %s to be removed, got %v", commit, err)
+	}
+	if contents, _ := ioutil.ReadFile(filepath.Join(root, "airflow/terraform/main.tf")); string(contents) != "v2" {
+		t.Errorf("expected paths outside the restored one to be left alone, got %q", contents)
+	}
+	if head := run("rev-parse", "HEAD"); head == commit {
+		t.Errorf("expected HEAD to stay where it was")
+	}
+}
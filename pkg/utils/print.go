@@ -5,6 +5,7 @@ import (
 	"github.com/fatih/color"
 	"golang.org/x/crypto/ssh/terminal"
 	"fmt"
+	"io"
 	"os"
 	"syscall"
 	"strings"
@@ -66,6 +67,14 @@ func Highlight(line string, args... interface{}) {
 	color.New(color.Bold).Printf(line, args...)
 }
 
+func Fsuccess(w io.Writer, line string, args... interface{}) {
+	color.New(color.FgGreen, color.Bold).Fprintf(w, line, args...)
+}
+
+func Fhighlight(w io.Writer, line string, args... interface{}) {
+	color.New(color.Bold).Fprintf(w, line, args...)
+}
+
 func Note(line string, args... interface{}) {
 	Warn("**NOTE** :: ")
 	Highlight(line, args...)
@@ -3,10 +3,19 @@ package utils
 import (
 	"os"
 	"path/filepath"
+	"github.com/pluralsh/plural/pkg/environment"
 	"github.com/pluralsh/plural/pkg/utils/git"
 )
 
+// ProjectRoot finds the directory holding the root workspace.yaml, which is shared by
+// every environment
 func ProjectRoot() (root string, found bool) {
+	if environment.Current() != "" {
+		if top, err := git.TopLevel(); err == nil {
+			return top, Exists(filepath.Join(top, "workspace.yaml"))
+		}
+	}
+
 	root, _ = os.Getwd()
 	found = false
 
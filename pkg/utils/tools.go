@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var versionArgs = map[string][]string{
+	"helm":      {"version", "--short"},
+	"kubectl":   {"version", "--client", "-o", "json"},
+	"terraform": {"version"},
+	"git":       {"--version"},
+}
+
+var semverRegex = regexp.MustCompile(`v?(\d+\.\d+\.\d+)`)
+
+// ToolVersion returns the semantic version of the tool that would actually be run,
+// honoring binaries vendored in <root>/bin
+func ToolVersion(tool string) (string, error) {
+	ok, path := Which(tool)
+	if !ok {
+		return "", fmt.Errorf("%s not installed", tool)
+	}
+
+	args, ok := versionArgs[tool]
+	if !ok {
+		args = []string{"version"}
+	}
+
+	out, err := exec.Command(path, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not determine the version of %s: %s", tool, err)
+	}
+
+	matches := semverRegex.FindStringSubmatch(string(out))
+	if len(matches) < 2 {
+		return "", fmt.Errorf("could not parse the version of %s from %q", tool, string(out))
+	}
+	return matches[1], nil
+}
+
+// PinnableTools are the tools whose versions can be constrained in workspace.yaml
+var PinnableTools = []string{"terraform", "helm", "kubectl"}
+
+var (
+	toolConstraints = map[string]*semver.Constraints{}
+	toolChecks      = map[string]error{}
+	toolMut         sync.Mutex
+)
+
+// ConstrainTools sets the semver constraints (eg "~1.0" or ">= 3.5, < 4") each tool
+// must satisfy before plural will shell out to it
+func ConstrainTools(constraints map[string]string) error {
+	parsed := map[string]*semver.Constraints{}
+	for tool, constraint := range constraints {
+		if !pinnable(tool) {
+			return fmt.Errorf("can't pin the version of %s, only %s are supported", tool, strings.Join(PinnableTools, ", "))
+		}
+
+		c, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("invalid version constraint %q for %s: %s", constraint, tool, err)
+		}
+		parsed[tool] = c
+	}
+
+	toolMut.Lock()
+	defer toolMut.Unlock()
+	toolConstraints = parsed
+	toolChecks = map[string]error{}
+	return nil
+}
+
+// CheckTool fails if tool doesn't satisfy its constraint, if it has one.  The result
+// is remembered, so it's cheap to call before every command
+func CheckTool(tool string) error {
+	toolMut.Lock()
+	defer toolMut.Unlock()
+	constraint, ok := toolConstraints[tool]
+	if !ok {
+		return nil
+	}
+
+	if err, ok := toolChecks[tool]; ok {
+		return err
+	}
+
+	err := checkTool(tool, constraint)
+	toolChecks[tool] = err
+	return err
+}
+
+// CheckTools checks every constrained tool.  Like CheckTool, each is only checked once
+func CheckTools() error {
+	toolMut.Lock()
+	tools := make([]string, 0, len(toolConstraints))
+	for tool := range toolConstraints {
+		tools = append(tools, tool)
+	}
+	toolMut.Unlock()
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		if err := CheckTool(tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkTool(tool string, constraint *semver.Constraints) error {
+	vsn, err := ToolVersion(tool)
+	if err != nil {
+		return err
+	}
+
+	v, err := semver.NewVersion(vsn)
+	if err != nil {
+		return fmt.Errorf("could not parse the version of %s: %s", tool, err)
+	}
+
+	if !constraint.Check(v) {
+		_, path := Which(tool)
+		return fmt.Errorf("%s %s (at %s) does not satisfy the constraint %q in the tools section of workspace.yaml, install a matching version or drop it into the bin directory of your repo", tool, vsn, path, constraint.String())
+	}
+	return nil
+}
+
+func pinnable(tool string) bool {
+	for _, t := range PinnableTools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
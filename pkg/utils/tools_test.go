@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConstrainTools(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints map[string]string
+		err         string
+	}{
+		{
+			name:        "valid",
+			constraints: map[string]string{"helm": ">= 3.5, < 4", "terraform": "~1.0"},
+		},
+		{
+			name:        "unsupported tool",
+			constraints: map[string]string{"kustomize": "~4.0"},
+			err:         "can't pin the version of kustomize",
+		},
+		{
+			name:        "invalid constraint",
+			constraints: map[string]string{"helm": "three"},
+			err:         `invalid version constraint "three" for helm`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ConstrainTools(test.constraints)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+	ConstrainTools(nil)
+}
+
+func TestCheckTool(t *testing.T) {
+	bin, err := ioutil.TempDir("", "tools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+
+	helm := "#!/bin/sh\necho v3.5.2+g167aac7\n"
+	if err := ioutil.WriteFile(filepath.Join(bin, "helm"), []byte(helm), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+	defer ConstrainTools(nil)
+
+	tests := []struct {
+		constraint string
+		ok         bool
+	}{
+		{constraint: ">= 3.5, < 4", ok: true},
+		{constraint: "~3.4", ok: false},
+		{constraint: "^3", ok: true},
+	}
+
+	for _, test := range tests {
+		if err := ConstrainTools(map[string]string{"helm": test.constraint}); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckTool("helm"); (err == nil) != test.ok {
+			t.Errorf("CheckTool(helm) with %q = %v", test.constraint, err)
+		}
+		// tools without a constraint are never checked
+		if err := CheckTool("terraform"); err != nil {
+			t.Errorf("expected unconstrained terraform to pass, got %s", err)
+		}
+	}
+}
@@ -9,13 +9,16 @@ import (
 type SafeGraph struct {
 	Graph   *toposort.Graph
 	Present map[string]bool
+	nodes   []string
+	edges   map[string][]string
 }
 
 func Graph(size int) *SafeGraph {
-	return &SafeGraph{Graph: toposort.NewGraph(size), Present: make(map[string]bool)}
+	return &SafeGraph{Graph: toposort.NewGraph(size), Present: make(map[string]bool), edges: make(map[string][]string)}
 }
 
 func (g *SafeGraph) AddNode(name string) bool {
+	g.nodes = append(g.nodes, name)
 	return g.Graph.AddNode(name)
 }
 
@@ -25,9 +28,55 @@ func (g *SafeGraph) AddEdge(in, out string) bool {
 		return false
 	}
 	g.Present[key] = true
+	g.edges[in] = append(g.edges[in], out)
 	return g.Graph.AddEdge(in, out)
 }
 
 func (g *SafeGraph) Topsort() ([]string, bool) {
 	return g.Graph.Toposort()
 }
+
+// Cycle finds a cycle in the graph if there is one, returning the nodes along it
+// with the first node repeated at the end, eg [a b a]
+func (g *SafeGraph) Cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	stack := make([]string, 0)
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		stack = append(stack, node)
+		for _, next := range g.edges[node] {
+			switch state[next] {
+			case visiting:
+				for i, n := range stack {
+					if n == next {
+						return append(append([]string{}, stack[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for _, node := range g.nodes {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    [][2]string
+		expected []string
+	}{
+		{name: "acyclic", edges: [][2]string{{"a", "b"}, {"b", "c"}, {"a", "c"}}},
+		{name: "self loop", edges: [][2]string{{"a", "a"}}, expected: []string{"a", "a"}},
+		// the edge into the cycle isn't part of it
+		{name: "cycle past the start", edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}}, expected: []string{"b", "c", "b"}},
+		{name: "disconnected", edges: [][2]string{{"a", "b"}, {"x", "y"}, {"y", "x"}}, expected: []string{"x", "y", "x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := Graph(len(test.edges))
+			for _, edge := range test.edges {
+				g.AddNode(edge[0])
+				g.AddNode(edge[1])
+				g.AddEdge(edge[0], edge[1])
+			}
+
+			if cycle := g.Cycle(); !reflect.DeepEqual(cycle, test.expected) {
+				t.Errorf("Cycle() = %v, expected %v", cycle, test.expected)
+			}
+		})
+	}
+}
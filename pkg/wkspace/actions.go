@@ -5,23 +5,35 @@ import (
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/executor"
 	"os"
+	"os/exec"
 	"fmt"
 	"time"
 	"strings"
 	"path/filepath"
 )
 
-func execSuppressed(command string, args ...string) (err error) {
+func execSuppressed(report *executor.RepoReport, name, command string, args ...string) (err error) {
+	step := executor.NewStepReport(name, command, args...)
+	var out *executor.OutputWriter
 	for retry := 2; retry >= 0; retry-- {
 		utils.Highlight("%s %s ~> ", command, strings.Join(args, " "))
-		cmd, out := executor.SuppressedCommand(command, args...)
+		var cmd *exec.Cmd
+		cmd, out = executor.SuppressedCommand(command, args...)
 		err = executor.RunCommand(cmd, out)
 		if err == nil {
 			break
 		}
 		fmt.Printf("retrying command, number of retries remaining: %d\n", retry)
+		if retry > 0 {
+			step.Retries++
+		}
 	}
 
+	if err != nil {
+		step.Output = out.Format()
+	}
+	step.Finish(err)
+	report.AddStep(step)
 	return
 }
 
@@ -31,12 +43,12 @@ func (w *Workspace) DestroyHelm() error {
 	name := w.Installation.Repository.Name
 
 	ns := w.Config.Namespace(name)
-	if err := execSuppressed("helm", "get", "values", name, "-n", ns); err != nil {
-		fmt.Println("Helm already uninstalled, continuing...\n")
+	if err := execSuppressed(nil, "helm-check", "helm", "get", "values", name, "-n", ns); err != nil {
+		fmt.Println("Helm already uninstalled, continuing...")
 		return nil
 	}
 
-	return execSuppressed("helm", "del", name, "-n", ns)
+	return execSuppressed(w.Report, "helm-delete", "helm", "del", name, "-n", ns)
 }
 
 func (w *Workspace) Bounce() error {
@@ -81,7 +93,7 @@ func (w *Workspace) DestroyTerraform() error {
 	time.AfterFunc(1 * time.Minute, func() {
 		kube, err := utils.Kubernetes()
 		if err != nil {
-			fmt.Println("could not set up k8s client due to %s", err)
+			fmt.Printf("could not set up k8s client due to %s\n", err)
 			return
 		}
 
@@ -90,9 +102,9 @@ func (w *Workspace) DestroyTerraform() error {
 	})
 
 	os.Chdir(path)
-	if err := execSuppressed("terraform", "init", "-upgrade"); err != nil {
+	if err := execSuppressed(w.Report, "terraform-init", "terraform", "init", "-upgrade"); err != nil {
 		return err
 	}
 
-	return execSuppressed("terraform", "destroy", "-auto-approve")
+	return execSuppressed(w.Report, "terraform-destroy", "terraform", "destroy", "-auto-approve")
 }
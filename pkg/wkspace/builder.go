@@ -10,7 +10,6 @@ import (
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/crypto"
-	"github.com/pluralsh/plural/pkg/diff"
 	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/provider"
@@ -27,6 +26,7 @@ type Workspace struct {
 	Manifest     *manifest.ProjectManifest
 	Context      *manifest.Context
 	Links        *manifest.Links
+	Report       *executor.RepoReport
 }
 
 func New(client *api.Client, inst *api.Installation) (*Workspace, error) {
@@ -57,6 +57,9 @@ func New(client *api.Client, inst *api.Installation) (*Workspace, error) {
 	var links *manifest.Links
 	if err == nil {
 		links = man.Links
+		if ci, err = pinCharts(ci, man, client.GetVersions); err != nil {
+			return nil, err
+		}
 	}
 
 	wk := &Workspace{
@@ -161,16 +164,26 @@ func (wk *Workspace) buildExecution(repoRoot string) error {
 
 	exec, _ := executor.GetExecution(filepath.Join(wkspaceRoot), "deploy")
 
-	return executor.DefaultExecution(name, exec).Flush(repoRoot)
+	execution, err := executor.DefaultExecution(name, exec)
+	if err != nil {
+		return err
+	}
+
+	return execution.Flush(repoRoot)
 }
 
 func (wk *Workspace) buildDiff(repoRoot string) error {
 	name := wk.Installation.Repository.Name
 	wkspaceRoot := filepath.Join(repoRoot, name)
 
-	d, _ := diff.GetDiff(filepath.Join(wkspaceRoot), "diff")
+	d, _ := executor.GetExecution(filepath.Join(wkspaceRoot), executor.DiffPipeline)
+
+	df, err := executor.DefaultPipeline(executor.DiffPipeline, name, d)
+	if err != nil {
+		return err
+	}
 
-	return diff.DefaultDiff(name, d).Flush(repoRoot)
+	return df.Flush(repoRoot)
 }
 
 func DiffedRepos() ([]string, error) {
@@ -0,0 +1,181 @@
+package wkspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+const fieldManager = "plural"
+
+// DiffCrds compares every manifest in crds/ against the live cluster by server side dry-run applying
+// it, and writes a unified diff of the result to diffs/<repo>/crds
+func (m *MinimalWorkspace) DiffCrds() error {
+	diffFolder, err := m.constructDiffFolder()
+	if err != nil {
+		return err
+	}
+
+	outfile, err := os.Create(filepath.Join(diffFolder, "crds"))
+	if err != nil {
+		return err
+	}
+	defer outfile.Close()
+
+	if empty, err := utils.IsEmpty("crds"); err != nil || empty {
+		return err
+	}
+
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kube.Kube.Discovery()))
+	out := &diff.TeeWriter{File: outfile}
+	failed := []string{}
+	err = filepath.Walk("crds", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		objs, err := utils.ParseYaml(contents)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			if err := diffObject(out, kube.Dynamic, mapper, obj); err != nil {
+				name := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+				fmt.Fprintf(out, "# %s (%s): dry run failed: %s\n", name, path, err)
+				failed = append(failed, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not diff [%s] against the cluster", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func diffObject(out io.Writer, client dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+
+	var resource dynamic.ResourceInterface = client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = metav1.NamespaceDefault
+		}
+		resource = client.Resource(mapping.Resource).Namespace(ns)
+	}
+
+	ctx := context.Background()
+	live, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		live = nil
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	force := true
+	applied, err := resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: fieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return err
+	}
+
+	before, err := normalize(live)
+	if err != nil {
+		return err
+	}
+
+	after, err := normalize(applied)
+	if err != nil {
+		return err
+	}
+
+	if before == after {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s/%s", strings.ToLower(gvk.Kind), obj.GetName())
+	from := "live/" + name
+	if live == nil {
+		from = "/dev/null"
+	}
+
+	res, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: from,
+		ToFile:   "applied/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(out, res)
+	return err
+}
+
+// normalize strips the fields the api server manages itself, so only meaningful changes show up in the diff
+func normalize(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	obj = obj.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	res, err := yaml.Marshal(obj.Object)
+	return string(res), err
+}
@@ -0,0 +1,77 @@
+package wkspace
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func crd(metadata map[string]interface{}, served bool) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   metadata,
+		"spec":       map[string]interface{}{"versions": []interface{}{map[string]interface{}{"name": "v1", "served": served}}},
+		"status":     map[string]interface{}{"acceptedNames": map[string]interface{}{"kind": "Foo"}},
+	}}
+}
+
+func TestNormalize(t *testing.T) {
+	desired := crd(map[string]interface{}{"name": "foos.plural.sh"}, true)
+	live := crd(map[string]interface{}{
+		"name":              "foos.plural.sh",
+		"uid":               "1234",
+		"resourceVersion":   "42",
+		"generation":        int64(3),
+		"creationTimestamp": "2021-01-01T00:00:00Z",
+		"managedFields":     []interface{}{map[string]interface{}{"manager": "plural"}},
+		"annotations":       map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+	}, true)
+
+	before, err := normalize(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := normalize(desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != after {
+		t.Errorf("expected fields managed by the api server to be ignored, got\n%s\nand\n%s", before, after)
+	}
+	if _, ok := live.Object["status"]; !ok {
+		t.Errorf("expected normalize not to modify the live object")
+	}
+
+	changed, err := normalize(crd(map[string]interface{}{"name": "foos.plural.sh"}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == after {
+		t.Errorf("expected a spec change to show up")
+	}
+
+	if empty, err := normalize(nil); err != nil || empty != "" {
+		t.Errorf("expected a missing object to normalize to nothing, got %q", empty)
+	}
+}
+
+func TestParseCrds(t *testing.T) {
+	out := `--- live/customresourcedefinition/foos.plural.sh
++++ applied/customresourcedefinition/foos.plural.sh
+@@ -5 +5 @@
+-    served: true
++    served: false
+# CustomResourceDefinition/bars.plural.sh (crds/bar.yaml): dry run failed: forbidden
+--- /dev/null
++++ applied/customresourcedefinition/bazs.plural.sh
+@@ -0,0 +1 @@
++apiVersion: apiextensions.k8s.io/v1
+`
+	expected := []string{"customresourcedefinition/foos.plural.sh", "customresourcedefinition/bazs.plural.sh"}
+	if changed := diff.ParseCrds(out); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("ParseCrds() = %v, expected %v", changed, expected)
+	}
+}
@@ -0,0 +1,297 @@
+package wkspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+// DepEdge means From depends on To, for each of Types (helm or terraform)
+type DepEdge struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Types []string `json:"types"`
+}
+
+// DepGraph is the dependency graph between the repos of a workspace, along with a
+// cycle through it if there is one
+type DepGraph struct {
+	Nodes []string   `json:"nodes"`
+	Edges []*DepEdge `json:"edges"`
+	Cycle []string   `json:"cycle,omitempty"`
+}
+
+// LocalRepos lists every repo in the workspace, ie every directory with a manifest.yaml
+func LocalRepos() ([]string, error) {
+	root, err := git.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []string{}
+	for _, f := range files {
+		if f.IsDir() && utils.Exists(filepath.Join(root, f.Name(), "manifest.yaml")) {
+			repos = append(repos, f.Name())
+		}
+	}
+	return repos, nil
+}
+
+// LocalGraph builds the graph from each repo's manifest.yaml
+func LocalGraph(repos []string) (*DepGraph, error) {
+	deps := make(map[string][]*manifest.Dependency)
+	for _, repo := range repos {
+		man, err := manifest.Read(manifestPath(repo))
+		if err != nil {
+			return nil, err
+		}
+		deps[repo] = man.Dependencies
+	}
+	return buildGraph(repos, deps), nil
+}
+
+// ApiGraph builds the graph from the dependencies of the installed packages in the api
+func ApiGraph(installations []*api.Installation) (*DepGraph, error) {
+	deps, err := apiDependencies(installations)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]string, len(installations))
+	for i, inst := range installations {
+		repos[i] = inst.Repository.Name
+	}
+	return buildGraph(repos, deps), nil
+}
+
+func buildGraph(repos []string, deps map[string][]*manifest.Dependency) *DepGraph {
+	sorted := append([]string{}, repos...)
+	sort.Strings(sorted)
+	isRepo := make(map[string]bool)
+	for _, repo := range sorted {
+		isRepo[repo] = true
+	}
+
+	graph := &DepGraph{Nodes: sorted, Edges: []*DepEdge{}}
+	safe := utils.Graph(len(sorted))
+	for _, repo := range sorted {
+		safe.AddNode(repo)
+	}
+
+	for _, repo := range sorted {
+		byTarget := make(map[string]*DepEdge)
+		for _, dep := range deps[repo] {
+			if !isRepo[dep.Repo] {
+				continue
+			}
+
+			edge, ok := byTarget[dep.Repo]
+			if !ok {
+				edge = &DepEdge{From: repo, To: dep.Repo, Types: []string{}}
+				byTarget[dep.Repo] = edge
+				graph.Edges = append(graph.Edges, edge)
+				safe.AddEdge(repo, dep.Repo)
+			}
+			if dep.Type != "" && !contains(edge.Types, dep.Type) {
+				edge.Types = append(edge.Types, dep.Type)
+			}
+		}
+	}
+
+	graph.Cycle = safe.Cycle()
+	return graph
+}
+
+// Render formats the graph as dot, mermaid or json
+func (g *DepGraph) Render(format string) (string, error) {
+	switch format {
+	case "dot":
+		return g.dot(), nil
+	case "mermaid":
+		return g.mermaid(), nil
+	case "json":
+		io, err := json.MarshalIndent(g, "", "  ")
+		return string(io), err
+	default:
+		return "", fmt.Errorf("unsupported format %s, must be one of dot, mermaid or json", format)
+	}
+}
+
+// inCycle reports whether the edge from a to b is part of the graph's cycle
+func (g *DepGraph) inCycle(a, b string) bool {
+	for i := 0; i+1 < len(g.Cycle); i++ {
+		if g.Cycle[i] == a && g.Cycle[i+1] == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *DepGraph) dot() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph plural {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		attrs := ""
+		if contains(g.Cycle, node) {
+			attrs = " [color=red, fontcolor=red]"
+		}
+		fmt.Fprintf(&buf, "  %q%s;\n", node, attrs)
+	}
+
+	for _, edge := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", strings.Join(edge.Types, ","))}
+		if len(edge.Types) == 1 && edge.Types[0] == "terraform" {
+			attrs = append(attrs, "style=dashed")
+		}
+		if g.inCycle(edge.From, edge.To) {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		fmt.Fprintf(&buf, "  %q -> %q [%s];\n", edge.From, edge.To, strings.Join(attrs, ", "))
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+var mermaidId = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+func (g *DepGraph) mermaid() string {
+	var buf bytes.Buffer
+	buf.WriteString("graph LR\n")
+	id := func(node string) string { return mermaidId.ReplaceAllString(node, "_") }
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&buf, "  %s[\"%s\"]\n", id(node), node)
+	}
+
+	cycleLinks := []string{}
+	for i, edge := range g.Edges {
+		arrow := "-->"
+		if len(edge.Types) == 1 && edge.Types[0] == "terraform" {
+			arrow = "-.->"
+		}
+
+		label := ""
+		if len(edge.Types) > 0 {
+			label = fmt.Sprintf("|%s|", strings.Join(edge.Types, ","))
+		}
+		fmt.Fprintf(&buf, "  %s %s%s %s\n", id(edge.From), arrow, label, id(edge.To))
+		if g.inCycle(edge.From, edge.To) {
+			cycleLinks = append(cycleLinks, fmt.Sprint(i))
+		}
+	}
+
+	if len(g.Cycle) > 0 {
+		buf.WriteString("  classDef cycle stroke:red,stroke-width:2px\n")
+		seen := map[string]bool{}
+		for _, node := range g.Cycle {
+			if !seen[node] {
+				fmt.Fprintf(&buf, "  class %s cycle\n", id(node))
+				seen[node] = true
+			}
+		}
+		fmt.Fprintf(&buf, "  linkStyle %s stroke:red,stroke-width:2px\n", strings.Join(cycleLinks, ","))
+	}
+	return buf.String()
+}
+
+// Impact is a repo depending on another, directly or through the repos in Via
+type Impact struct {
+	Repo string   `json:"repo"`
+	Via  []string `json:"via,omitempty"`
+}
+
+// Dependents finds every repo that depends on repo, directly or transitively, nearest first
+func (g *DepGraph) Dependents(repo string) []*Impact {
+	reverse := make(map[string][]string)
+	for _, edge := range g.Edges {
+		reverse[edge.To] = append(reverse[edge.To], edge.From)
+	}
+
+	// breadth first, so each dependent is reached along its shortest chain
+	via := map[string][]string{repo: {}}
+	queue := []string{repo}
+	impacts := []*Impact{}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, dependent := range reverse[next] {
+			if _, ok := via[dependent]; ok {
+				continue
+			}
+
+			chain := via[next]
+			if next != repo {
+				chain = append(append([]string{}, chain...), next)
+			}
+			via[dependent] = chain
+			impacts = append(impacts, &Impact{Repo: dependent, Via: chain})
+			queue = append(queue, dependent)
+		}
+	}
+	return impacts
+}
+
+// Path finds the shortest chain of dependencies leading from a to b, or nil if a
+// doesn't depend on b
+func (g *DepGraph) Path(a, b string) []*DepEdge {
+	forward := make(map[string][]*DepEdge)
+	for _, edge := range g.Edges {
+		forward[edge.From] = append(forward[edge.From], edge)
+	}
+
+	prev := map[string]*DepEdge{a: nil}
+	queue := []string{a}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if next == b && next != a {
+			break
+		}
+
+		for _, edge := range forward[next] {
+			if _, ok := prev[edge.To]; ok {
+				continue
+			}
+			prev[edge.To] = edge
+			queue = append(queue, edge.To)
+		}
+	}
+
+	if _, ok := prev[b]; !ok || a == b {
+		return nil
+	}
+
+	path := []*DepEdge{}
+	for node := b; node != a; node = prev[node].From {
+		path = append([]*DepEdge{prev[node]}, path...)
+	}
+	return path
+}
+
+// HasNode reports whether repo is in the graph
+func (g *DepGraph) HasNode(repo string) bool {
+	return contains(g.Nodes, repo)
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
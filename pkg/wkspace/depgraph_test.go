@@ -0,0 +1,80 @@
+package wkspace
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+)
+
+func TestCycleEdges(t *testing.T) {
+	// airflow -> postgres -> redis -> postgres, with redis also depending on bootstrap
+	graph := buildGraph([]string{"airflow", "bootstrap", "postgres", "redis"}, map[string][]*manifest.Dependency{
+		"airflow":  {{Repo: "postgres"}},
+		"postgres": {{Repo: "redis"}},
+		"redis":    {{Repo: "postgres"}, {Repo: "bootstrap"}},
+	})
+
+	if expected := []string{"postgres", "redis", "postgres"}; !reflect.DeepEqual(graph.Cycle, expected) {
+		t.Fatalf("Cycle = %v, expected %v", graph.Cycle, expected)
+	}
+
+	onCycle := []string{}
+	for _, edge := range graph.Edges {
+		if graph.inCycle(edge.From, edge.To) {
+			onCycle = append(onCycle, edge.From+"->"+edge.To)
+		}
+	}
+	if expected := []string{"postgres->redis", "redis->postgres"}; !reflect.DeepEqual(onCycle, expected) {
+		t.Errorf("edges on the cycle are %v, expected %v", onCycle, expected)
+	}
+}
+
+// graph is bootstrap <- postgres <- airflow, bootstrap <- grafana and
+// postgres <- airbyte, with airflow also depending on bootstrap directly
+func impactGraph() *DepGraph {
+	return buildGraph([]string{"airbyte", "airflow", "bootstrap", "grafana", "postgres"}, map[string][]*manifest.Dependency{
+		"postgres": {{Repo: "bootstrap", Type: "terraform"}, {Repo: "bootstrap", Type: "helm"}},
+		"airflow":  {{Repo: "postgres", Type: "helm"}, {Repo: "bootstrap", Type: "terraform"}},
+		"airbyte":  {{Repo: "postgres", Type: "helm"}},
+		"grafana":  {{Repo: "bootstrap", Type: "helm"}},
+	})
+}
+
+func TestDependents(t *testing.T) {
+	// direct dependents come first, then those reached through them
+	impacts := impactGraph().Dependents("bootstrap")
+	res := []string{}
+	for _, impact := range impacts {
+		res = append(res, impact.Repo+" via "+fmt.Sprint(impact.Via))
+	}
+	expected := []string{"airflow via []", "grafana via []", "postgres via []", "airbyte via [postgres]"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Dependents(bootstrap) = %v, expected %v", res, expected)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		from, to string
+		expected []string
+	}{
+		{from: "airbyte", to: "bootstrap", expected: []string{"airbyte->postgres [helm]", "postgres->bootstrap [terraform helm]"}},
+		// the direct edge beats the one through postgres
+		{from: "airflow", to: "bootstrap", expected: []string{"airflow->bootstrap [terraform]"}},
+		{from: "bootstrap", to: "airflow"},
+		{from: "airflow", to: "airflow"},
+	}
+
+	graph := impactGraph()
+	for _, test := range tests {
+		var res []string
+		for _, edge := range graph.Path(test.from, test.to) {
+			res = append(res, fmt.Sprintf("%s->%s %v", edge.From, edge.To, edge.Types))
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("Path(%s, %s) = %v, expected %v", test.from, test.to, res, test.expected)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package wkspace
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const (
+	DriftInSync  = "in-sync"
+	DriftDrifted = "drifted"
+	DriftDeleted = "deleted"
+	DriftError   = "error"
+	DriftNone    = "n/a"
+)
+
+var (
+	planSummary  = regexp.MustCompile(`Plan: \d+ to add, \d+ to change, \d+ to destroy`)
+	helmResource = regexp.MustCompile(`(?m)^\S.*, \S+ \(.*\) has (changed|been added|been removed):`)
+)
+
+// Drift describes how far a repo's live state has diverged from what's in git
+type Drift struct {
+	Repo      string `json:"repo"`
+	Terraform string `json:"terraform"`
+	Helm      string `json:"helm"`
+	Summary   string `json:"summary,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (d *Drift) Drifted() bool {
+	return d.Terraform == DriftDrifted || d.Helm == DriftDrifted || d.Helm == DriftDeleted
+}
+
+// Drift compares the live terraform and helm state of the repo against the workspace, without
+// writing to diffs/ or touching any step shas
+func (m *MinimalWorkspace) Drift(root string) *Drift {
+	drift := &Drift{Repo: m.Name, Terraform: DriftNone, Helm: DriftNone}
+	summaries := []string{}
+	failures := []string{}
+
+	tfDir := filepath.Join(root, m.Name, "terraform")
+	if utils.Exists(tfDir) {
+		status, summary, err := m.terraformDrift(tfDir)
+		drift.Terraform = status
+		if summary != "" {
+			summaries = append(summaries, summary)
+		}
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	chart := filepath.Join(root, m.Name, "helm", m.Name)
+	if utils.Exists(chart) {
+		status, summary, err := m.helmDrift(chart)
+		drift.Helm = status
+		if summary != "" {
+			summaries = append(summaries, summary)
+		}
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	drift.Summary = strings.Join(summaries, "; ")
+	drift.Error = strings.Join(failures, "; ")
+	return drift
+}
+
+func (m *MinimalWorkspace) terraformDrift(dir string) (string, string, error) {
+	if _, err := driftCmd(m, dir, "terraform", "init", "-input=false", "-no-color"); err != nil {
+		return DriftError, "", fmt.Errorf("terraform init failed: %s", err)
+	}
+
+	out, err := driftCmd(m, dir, "terraform", "plan", "-detailed-exitcode", "-input=false", "-lock=false", "-no-color")
+	switch exitCode(err) {
+	case 0:
+		return DriftInSync, "", nil
+	case 2:
+		return DriftDrifted, planSummary.FindString(out), nil
+	default:
+		return DriftError, "", fmt.Errorf("terraform plan failed: %s", lastLine(out))
+	}
+}
+
+func (m *MinimalWorkspace) helmDrift(chart string) (string, string, error) {
+	namespace := m.Config.Namespace(m.Name)
+	if out, err := driftCmd(m, chart, "helm", "status", m.Name, "-n", namespace); err != nil {
+		if strings.Contains(out, "not found") {
+			return DriftDeleted, "helm release is missing", nil
+		}
+		return DriftError, "", fmt.Errorf("helm status failed: %s", lastLine(out))
+	}
+
+	backup, err := templateVals(m.Name, chart)
+	if err == nil {
+		defer os.Rename(backup, filepath.Join(chart, "values.yaml"))
+	}
+
+	// the three way merge compares against the live objects, so it also catches manual kubectl edits
+	out, err := driftCmd(m, chart, "helm", "diff", "upgrade", "--install", "--reset-values", "--three-way-merge",
+		"--detailed-exitcode", "--no-color", "--namespace", namespace, m.Name, chart)
+	switch exitCode(err) {
+	case 0:
+		return DriftInSync, "", nil
+	case 2:
+		count := len(helmResource.FindAllString(out, -1))
+		return DriftDrifted, fmt.Sprintf("%d kubernetes resources differ", count), nil
+	default:
+		return DriftError, "", fmt.Errorf("helm diff failed: %s", lastLine(out))
+	}
+}
+
+func driftCmd(m *MinimalWorkspace, dir, program string, args ...string) (string, error) {
+	if err := utils.CheckTool(program); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	cmd := utils.MkCmd(m.Config, program, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	return buf.String(), err
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.ExitCode()
+	}
+	return -1
+}
+
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
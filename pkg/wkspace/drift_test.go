@@ -0,0 +1,88 @@
+package wkspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/config"
+)
+
+func TestDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		terraform string
+		helm      string
+		expected  *Drift
+		drifted   bool
+	}{
+		{
+			name:      "in sync",
+			terraform: "echo 'No changes.'; exit 0",
+			expected:  &Drift{Repo: "airflow", Terraform: DriftInSync, Helm: DriftNone},
+		},
+		{
+			name:      "terraform drift",
+			terraform: "echo 'Plan: 1 to add, 2 to change, 0 to destroy.'; exit 2",
+			expected:  &Drift{Repo: "airflow", Terraform: DriftDrifted, Helm: DriftNone, Summary: "Plan: 1 to add, 2 to change, 0 to destroy"},
+			drifted:   true,
+		},
+		{
+			name:      "terraform failure",
+			terraform: "echo 'Error: no valid credential sources found'; exit 1",
+			expected:  &Drift{Repo: "airflow", Terraform: DriftError, Helm: DriftNone, Error: "terraform plan failed: Error: no valid credential sources found"},
+		},
+		{
+			name:      "deleted release",
+			terraform: "exit 0",
+			helm:      "echo 'Error: release: not found'; exit 1",
+			expected:  &Drift{Repo: "airflow", Terraform: DriftInSync, Helm: DriftDeleted, Summary: "helm release is missing"},
+			drifted:   true,
+		},
+	}
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "drift")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+
+			// stand ins for terraform and helm, init always succeeds
+			bin := filepath.Join(root, "fakebin")
+			scripts := map[string]string{
+				"terraform": "#!/bin/sh\n[ \"$1\" = init ] && exit 0\n" + test.terraform + "\n",
+				"helm":      "#!/bin/sh\n" + test.helm + "\n",
+			}
+			dirs := []string{bin, filepath.Join(root, "airflow", "terraform")}
+			if test.helm != "" {
+				dirs = append(dirs, filepath.Join(root, "airflow", "helm", "airflow"))
+			}
+			for _, dir := range dirs {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, script := range scripts {
+				if err := ioutil.WriteFile(filepath.Join(bin, name), []byte(script), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+
+			m := &MinimalWorkspace{Name: "airflow", Config: &config.Config{}}
+			drift := m.Drift(root)
+			if !reflect.DeepEqual(drift, test.expected) {
+				t.Errorf("Drift() = %+v, expected %+v", drift, test.expected)
+			}
+			if drift.Drifted() != test.drifted {
+				t.Errorf("Drifted() = %v, expected %v", drift.Drifted(), test.drifted)
+			}
+		})
+	}
+}
@@ -2,13 +2,24 @@ package wkspace
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/manifest"
-	toposort "github.com/philopon/go-toposort"
+	"github.com/pluralsh/plural/pkg/utils"
 )
 
 type depsFetcher func(string) ([]*manifest.Dependency, error)
 
+// CycleError is returned when repos depend on each other in a loop
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("Cycle detected in dependency graph: %s", strings.Join(e.Cycle, " -> "))
+}
+
 func SortAndFilter(installations []*api.Installation) ([]string, error) {
 	names := make([]string, 0)
 	for _, inst := range installations {
@@ -22,21 +33,16 @@ func SortAndFilter(installations []*api.Installation) ([]string, error) {
 
 func TopSort(installations []*api.Installation) ([]*api.Installation, error) {
 	var repoMap = make(map[string]*api.Installation)
-	var depsMap = make(map[string][]*manifest.Dependency)
 	names := make([]string, len(installations))
-	client := api.NewClient()
-
 	for i, installation := range installations {
 		repo := installation.Repository.Name
 		repoMap[repo] = installation
 		names[i] = repo
+	}
 
-		ci, tf, err := client.GetPackageInstallations(installation.Repository.Id)
-		if err != nil {
-			return nil, err
-		}
-
-		depsMap[repo] = buildDependencies(repo, ci, tf)
+	depsMap, err := apiDependencies(installations)
+	if err != nil {
+		return nil, err
 	}
 
 	sortedNames, err := topsorter(names, func(repo string) ([]*manifest.Dependency, error) {
@@ -58,6 +64,22 @@ func TopSort(installations []*api.Installation) ([]*api.Installation, error) {
 	return sorted, nil
 }
 
+// apiDependencies fetches the dependencies of each installation from the api, keyed by repo
+func apiDependencies(installations []*api.Installation) (map[string][]*manifest.Dependency, error) {
+	depsMap := make(map[string][]*manifest.Dependency)
+	client := api.NewClient()
+	for _, installation := range installations {
+		repo := installation.Repository.Name
+		ci, tf, err := client.GetPackageInstallations(installation.Repository.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		depsMap[repo] = buildDependencies(repo, ci, tf)
+	}
+	return depsMap, nil
+}
+
 func TopSortNames(repos []string) ([]string, error) {
 	return topsorter(repos, func(repo string) ([]*manifest.Dependency, error) {
 		man, err := manifest.Read(manifestPath(repo))
@@ -69,9 +91,61 @@ func TopSortNames(repos []string) ([]string, error) {
 	})
 }
 
+// TopSortWaves groups repos into waves, where every repo in a wave only depends on
+// repos in earlier waves, so all repos within a single wave can be deployed concurrently
+func TopSortWaves(repos []string) ([][]string, error) {
+	cache := make(map[string][]*manifest.Dependency)
+	fetcher := func(repo string) ([]*manifest.Dependency, error) {
+		if deps, ok := cache[repo]; ok {
+			return deps, nil
+		}
+
+		man, err := manifest.Read(manifestPath(repo))
+		if err != nil {
+			return nil, err
+		}
+
+		cache[repo] = man.Dependencies
+		return man.Dependencies, nil
+	}
+
+	sorted, err := topsorter(repos, fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	return waves(sorted, fetcher)
+}
+
+func waves(sorted []string, fn depsFetcher) ([][]string, error) {
+	level := make(map[string]int)
+	result := make([][]string, 0)
+	for _, repo := range sorted {
+		deps, err := fn(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		lvl := 0
+		for _, dep := range deps {
+			if l, ok := level[dep.Repo]; ok && l+1 > lvl {
+				lvl = l + 1
+			}
+		}
+
+		level[repo] = lvl
+		if lvl == len(result) {
+			result = append(result, []string{})
+		}
+		result[lvl] = append(result[lvl], repo)
+	}
+
+	return result, nil
+}
+
 func topsorter(repos []string, fn depsFetcher) ([]string, error) {
 	seen := make(map[string]bool)
-	graph := toposort.NewGraph(len(repos))
+	graph := utils.Graph(len(repos))
 	isRepo := make(map[string]bool)
 	for _, repo := range repos {
 		isRepo[repo] = true
@@ -101,9 +175,9 @@ func topsorter(repos []string, fn depsFetcher) ([]string, error) {
 		}
 	}
 
-	sorted, ok := graph.Toposort()
+	sorted, ok := graph.Topsort()
 	if !ok {
-		return nil, fmt.Errorf("Cycle detected in dependency graph")
+		return nil, &CycleError{Cycle: graph.Cycle()}
 	}
 
 	// need to reverse the order
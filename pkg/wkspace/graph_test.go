@@ -0,0 +1,80 @@
+package wkspace
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+)
+
+func TestWaves(t *testing.T) {
+	tests := []struct {
+		name     string
+		deps     map[string][]string
+		expected [][]string
+		err      bool
+	}{
+		{
+			name:     "independent",
+			deps:     map[string][]string{"airflow": {}, "grafana": {}},
+			expected: [][]string{{"airflow", "grafana"}},
+		},
+		{
+			name:     "diamond",
+			deps:     map[string][]string{"bootstrap": {}, "postgres": {"bootstrap"}, "redis": {"bootstrap"}, "airflow": {"postgres", "redis"}},
+			expected: [][]string{{"bootstrap"}, {"postgres", "redis"}, {"airflow"}},
+		},
+		{
+			// a repo waits for its deepest dependency, and deps outside the workspace are ignored
+			name:     "uneven depths",
+			deps:     map[string][]string{"bootstrap": {}, "postgres": {"bootstrap"}, "grafana": {"bootstrap", "external"}, "airflow": {"postgres", "bootstrap"}},
+			expected: [][]string{{"bootstrap"}, {"grafana", "postgres"}, {"airflow"}},
+		},
+		{
+			name: "cycle",
+			deps: map[string][]string{"airflow": {"postgres"}, "postgres": {"airflow"}},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repos := []string{}
+			for repo := range test.deps {
+				repos = append(repos, repo)
+			}
+			sort.Strings(repos)
+			fetcher := func(repo string) ([]*manifest.Dependency, error) {
+				deps := []*manifest.Dependency{}
+				for _, dep := range test.deps[repo] {
+					deps = append(deps, &manifest.Dependency{Repo: dep})
+				}
+				return deps, nil
+			}
+
+			sorted, err := topsorter(repos, fetcher)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected a cycle to fail, got %v", sorted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := waves(sorted, fetcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the order within a wave doesn't matter
+			for _, wave := range res {
+				sort.Strings(wave)
+			}
+			if !reflect.DeepEqual(res, test.expected) {
+				t.Errorf("waves() = %v, expected %v", res, test.expected)
+			}
+		})
+	}
+}
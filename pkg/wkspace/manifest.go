@@ -1,6 +1,9 @@
 package wkspace
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/manifest"
 )
@@ -12,6 +15,7 @@ func (wk *Workspace) BuildManifest(prev *manifest.Manifest) *manifest.Manifest {
 
 	for i, ci := range wk.Charts {
 		charts[i] = buildChartManifest(ci)
+		charts[i].Pinned = prev.Pinned(ci.Chart.Name)
 	}
 	for i, ti := range wk.Terraform {
 		terraform[i] = buildTerraformManifest(ti)
@@ -37,32 +41,30 @@ func (wk *Workspace) BuildManifest(prev *manifest.Manifest) *manifest.Manifest {
 func buildDependencies(repo string, charts []*api.ChartInstallation, tfs []*api.TerraformInstallation) []*manifest.Dependency {
 	var deps []*manifest.Dependency
 	var seen = make(map[string]bool)
+	add := func(dep *api.Dependency, kind string) {
+		typ := strings.ToLower(dep.Type)
+		if typ == "" {
+			typ = kind
+		}
+
+		key := dep.Repo + ":" + typ
+		if dep.Repo == repo || seen[key] {
+			return
+		}
+
+		deps = append(deps, &manifest.Dependency{Repo: dep.Repo, Type: typ})
+		seen[key] = true
+	}
 
 	for _, chart := range charts {
 		for _, dep := range chart.Chart.Dependencies.Dependencies {
-			_, ok := seen[dep.Repo]
-			if ok {
-				continue
-			}
-
-			if dep.Repo != repo {
-				deps = append(deps, &manifest.Dependency{dep.Repo})
-				seen[dep.Repo] = true
-			}
+			add(dep, "helm")
 		}
 	}
 
 	for _, tf := range tfs {
 		for _, dep := range tf.Terraform.Dependencies.Dependencies {
-			_, ok := seen[dep.Repo]
-			if ok {
-				continue
-			}
-
-			if dep.Repo != repo {
-				deps = append(deps, &manifest.Dependency{dep.Repo})
-				seen[dep.Repo] = true
-			}
+			add(dep, "terraform")
 		}
 	}
 
@@ -72,7 +74,41 @@ func buildDependencies(repo string, charts []*api.ChartInstallation, tfs []*api.
 func buildChartManifest(chartInstallation *api.ChartInstallation) *manifest.ChartManifest {
 	chart := chartInstallation.Chart
 	version := chartInstallation.Version
-	return &manifest.ChartManifest{chart.Id, chart.Name, version.Id, version.Version}
+	return &manifest.ChartManifest{Id: chart.Id, Name: chart.Name, VersionId: version.Id, Version: version.Version}
+}
+
+// pinCharts swaps the version of every chart pinned in prev in for the one installed in
+// the api, using versions to look up the pinned version
+func pinCharts(charts []*api.ChartInstallation, prev *manifest.Manifest, versions func(chartId string) ([]*api.Version, error)) ([]*api.ChartInstallation, error) {
+	pinned := make([]*api.ChartInstallation, len(charts))
+	for i, ci := range charts {
+		pinned[i] = ci
+		chart := prev.Chart(ci.Chart.Name)
+		if chart == nil || !chart.Pinned || chart.VersionId == ci.Version.Id {
+			continue
+		}
+
+		vsns, err := versions(ci.Chart.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		var version *api.Version
+		for _, v := range vsns {
+			if v.Id == chart.VersionId {
+				version = v
+			}
+		}
+		if version == nil {
+			return nil, fmt.Errorf("%s is pinned to version %s, which no longer exists", ci.Chart.Name, chart.Version)
+		}
+
+		// installations are cached and shared, so pin a copy
+		copied := *ci
+		copied.Version = version
+		pinned[i] = &copied
+	}
+	return pinned, nil
 }
 
 func buildTerraformManifest(tfInstallation *api.TerraformInstallation) *manifest.TerraformManifest {
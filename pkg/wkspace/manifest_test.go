@@ -0,0 +1,120 @@
+package wkspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+)
+
+type stubProvider struct {
+	provider.Provider
+}
+
+func (p *stubProvider) Name() string                    { return "aws" }
+func (p *stubProvider) Cluster() string                 { return "cluster" }
+func (p *stubProvider) Project() string                 { return "project" }
+func (p *stubProvider) Region() string                  { return "us-east-2" }
+func (p *stubProvider) Bucket() string                  { return "bucket" }
+func (p *stubProvider) Context() map[string]interface{} { return map[string]interface{}{} }
+
+func writeManifest(t *testing.T, root, version, versionId string) {
+	man := &manifest.Manifest{
+		Name:   "airflow",
+		Charts: []*manifest.ChartManifest{{Id: "chart", Name: "airflow", Version: version, VersionId: versionId}},
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "airflow"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := man.Write(filepath.Join(root, "airflow", "manifest.yaml")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildAfterPromotion(t *testing.T) {
+	root, err := ioutil.TempDir("", "promote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	from, to := filepath.Join(root, "prod"), filepath.Join(root, "dev")
+	writeManifest(t, from, "0.2.0", "v2")
+	writeManifest(t, to, "0.1.0", "v1")
+
+	promotions, err := manifest.PlanPromotion(from, to, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.ApplyPromotions(to, promotions); err != nil {
+		t.Fatal(err)
+	}
+
+	chart := &api.Chart{Id: "chart", Name: "airflow", Dependencies: &api.Dependencies{}}
+	versions := func(chartId string) ([]*api.Version, error) {
+		return []*api.Version{{Id: "v1", Version: "0.1.0"}, {Id: "v2", Version: "0.2.0"}}, nil
+	}
+
+	// the api still has the old version installed, so the pin is what keeps the promotion
+	installed := []*api.ChartInstallation{{Id: "ci", Chart: chart, Version: &api.Version{Id: "v1", Version: "0.1.0"}}}
+	for i := 0; i < 2; i++ {
+		prev, err := manifest.Read(filepath.Join(to, "airflow", "manifest.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		charts, err := pinCharts(installed, prev, versions)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wk := &Workspace{
+			Provider:     &stubProvider{},
+			Installation: &api.Installation{Repository: &api.Repository{Id: "repo", Name: "airflow"}},
+			Charts:       charts,
+		}
+		man := wk.BuildManifest(prev)
+		if len(man.Charts) != 1 || man.Charts[0].Version != "0.2.0" || man.Charts[0].VersionId != "v2" || !man.Charts[0].Pinned {
+			t.Fatalf("build %d: expected the promoted version to be pinned, got %+v", i, man.Charts[0])
+		}
+		if err := man.Write(filepath.Join(to, "airflow", "manifest.yaml")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if installed[0].Version.Id != "v1" {
+		t.Error("pinning shouldn't modify the shared api installation")
+	}
+}
+
+func TestPinChartsUnpinned(t *testing.T) {
+	prev := &manifest.Manifest{Charts: []*manifest.ChartManifest{{Name: "airflow", VersionId: "v1", Version: "0.1.0"}}}
+	installed := []*api.ChartInstallation{{Chart: &api.Chart{Id: "chart", Name: "airflow"}, Version: &api.Version{Id: "v2", Version: "0.2.0"}}}
+	charts, err := pinCharts(installed, prev, func(string) ([]*api.Version, error) {
+		t.Fatal("unpinned charts shouldn't look up versions")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if charts[0].Version.Id != "v2" {
+		t.Errorf("expected the installed version, got %s", charts[0].Version.Id)
+	}
+}
+
+func TestPinChartsMissingVersion(t *testing.T) {
+	prev := &manifest.Manifest{Charts: []*manifest.ChartManifest{{Name: "airflow", VersionId: "v0", Version: "0.0.1", Pinned: true}}}
+	installed := []*api.ChartInstallation{{Chart: &api.Chart{Id: "chart", Name: "airflow"}, Version: &api.Version{Id: "v2", Version: "0.2.0"}}}
+	_, err := pinCharts(installed, prev, func(string) ([]*api.Version, error) {
+		return []*api.Version{{Id: "v2", Version: "0.2.0"}}, nil
+	})
+	if err == nil {
+		t.Error("expected pinning to a deleted version to fail")
+	}
+}
@@ -116,6 +116,10 @@ func (m *MinimalWorkspace) runDiff(command string, args ...string) error {
 	}
 	defer outfile.Close()
 
+	if err := utils.CheckTool(command); err != nil {
+		return err
+	}
+
 	cmd := exec.Command(command, args...)
 	cmd.Stdout = &diff.TeeWriter{File: outfile}
 	cmd.Stderr = os.Stdout
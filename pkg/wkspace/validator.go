@@ -16,6 +16,10 @@ func Preflight() error {
 		}
 	}
 
+	if err := utils.CheckTools(); err != nil {
+		return utils.HighlightError(err)
+	}
+
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	if _, err := cmd.CombinedOutput(); err != nil {
 		return utils.HighlightError(fmt.Errorf("not in a git repository, or repository has no initial commit"))